@@ -0,0 +1,214 @@
+//go:generate go run fetch_runtime.go
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourusername/particles-go/particles"
+)
+
+// embeddedAssets are the client assets compiled into the binary.
+// assets/js holds particles.min.js once "go generate" has fetched it.
+//
+//go:embed assets/js gravity.js demo.html
+var embeddedAssets embed.FS
+
+// Asset URLs
+const (
+	runtimePath = "/js/particles.min.js"
+	demoPath    = "/demo/"
+)
+
+// runtimeCDN is where pages load particles.js from when the binary was
+// built without it
+const runtimeCDN = "https://cdnjs.cloudflare.com/ajax/libs/particles.js/2.0.0/particles.min.js"
+
+// runtimeIntegrity is the Subresource Integrity value of the particles.js
+// release, as pinned in fetch_runtime.go. Both the embedded and the CDN
+// copy must match it.
+const runtimeIntegrity = "sha512-Kef5sc7gfTacR7TZKelcrRs15ipf7+t+n7Zh6mKNJbmW+/RRdCW9nwfLn4YX0s2nO6Kv6vzx7SrWJ6gNRE9tBQ=="
+
+// asset is an embedded file ready to be served
+type asset struct {
+	name        string
+	data        []byte
+	contentType string
+	hash        string // Hex SHA-256 of data
+}
+
+func newAsset(name string, data []byte) *asset {
+	sum := sha256.Sum256(data)
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return &asset{
+		name:        name,
+		data:        data,
+		contentType: contentType,
+		hash:        hex.EncodeToString(sum[:]),
+	}
+}
+
+// assetServer serves the embedded client assets. Files in dir, when set,
+// take precedence so that assets can be replaced without a rebuild.
+//
+// Every asset is also served under a fingerprinted URL embedding a hash of
+// its content, listed in manifest, which browsers may cache forever.
+type assetServer struct {
+	dir      string
+	assets   map[string]*asset // By URL path
+	manifest *particles.AssetManifest
+}
+
+// newAssetServer loads the embedded assets: everything in assets/js under
+// /js/, gravity.js under /js/ and next to the control-panel demo, and the
+// demo itself under /demo/
+func newAssetServer(dir string) (*assetServer, error) {
+	s := &assetServer{
+		dir:      dir,
+		assets:   make(map[string]*asset),
+		manifest: particles.NewAssetManifest(),
+	}
+
+	err := fs.WalkDir(embeddedAssets, "assets/js", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := embeddedAssets.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		s.add("/js/"+strings.TrimPrefix(name, "assets/js/"), data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if runtime := s.assets[runtimePath]; runtime != nil {
+		if err := particles.CheckIntegrity(runtimeIntegrity, runtime.data); err != nil {
+			return nil, fmt.Errorf("embedded particles.js is not the pinned release: %v", err)
+		}
+	}
+
+	gravity, err := embeddedAssets.ReadFile("gravity.js")
+	if err != nil {
+		return nil, err
+	}
+	s.add("/js/gravity.js", gravity)
+	s.add(demoPath+"gravity.js", gravity)
+
+	demo, err := embeddedAssets.ReadFile("demo.html")
+	if err != nil {
+		return nil, err
+	}
+	// Load the embedded runtime instead of the CDN copy when there is one
+	if s.assets[runtimePath] != nil {
+		demo = bytes.ReplaceAll(demo, []byte(runtimeCDN), []byte(runtimePath))
+	}
+	s.add(demoPath, demo)
+
+	// Fingerprint the files overriding assets, as they are what browsers
+	// get; changes made to them later are picked up on restart. The
+	// runtime may also be provided on disk alone.
+	urlPaths := []string{runtimePath}
+	for urlPath := range s.assets {
+		if urlPath != runtimePath {
+			urlPaths = append(urlPaths, urlPath)
+		}
+	}
+	for _, urlPath := range urlPaths {
+		if name, ok := s.diskFile(urlPath); ok {
+			data, err := os.ReadFile(name)
+			if err != nil {
+				return nil, err
+			}
+			s.add(urlPath, data)
+		}
+	}
+
+	return s, nil
+}
+
+func (s *assetServer) add(urlPath string, data []byte) {
+	name := path.Base(urlPath)
+	if strings.HasSuffix(urlPath, "/") {
+		// Pages link to their own relative assets, so their URL stays put
+		s.assets[urlPath] = newAsset("index.html", data)
+		return
+	}
+	s.assets[urlPath] = newAsset(name, data)
+	s.manifest.Add(urlPath, data)
+}
+
+// has reports whether there is an asset at urlPath
+func (s *assetServer) has(urlPath string) bool {
+	if _, ok := s.assets[urlPath]; ok {
+		return true
+	}
+	_, ok := s.diskFile(urlPath)
+	return ok
+}
+
+// diskFile returns the file in dir overriding the asset at urlPath
+func (s *assetServer) diskFile(urlPath string) (string, bool) {
+	if s.dir == "" || strings.HasSuffix(urlPath, "/") {
+		return "", false
+	}
+	name := filepath.Join(s.dir, filepath.FromSlash(path.Clean(urlPath)))
+	info, err := os.Stat(name)
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+	return name, true
+}
+
+// ServeHTTP serves an asset. Responses carry the asset's hash as ETag, so
+// browsers revalidate cheaply, and fingerprinted URLs are cacheable for a
+// year as their content never changes.
+func (s *assetServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		particles.WriteError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	urlPath := path.Clean(r.URL.Path)
+	if strings.HasSuffix(r.URL.Path, "/") && urlPath != "/" {
+		urlPath += "/"
+	}
+
+	cacheControl := "no-cache"
+	if info, ok := s.manifest.LookupFingerprinted(urlPath); ok {
+		urlPath = info.Path
+		cacheControl = "public, max-age=31536000, immutable"
+	} else if name, ok := s.diskFile(urlPath); ok {
+		http.ServeFile(w, r, name)
+		return
+	}
+
+	a, ok := s.assets[urlPath]
+	if !ok {
+		particles.WriteError(w, r, http.StatusNotFound, "Not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", a.contentType)
+	w.Header().Set("ETag", `"`+a.hash+`"`)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, a.name, time.Time{}, bytes.NewReader(a.data))
+}
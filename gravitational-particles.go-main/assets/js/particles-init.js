@@ -0,0 +1,62 @@
+// Starts every particles container rendered by the particles-go Hugo module.
+// Inline configurations come from <script data-particles-config="id"> tags,
+// remote ones from the container's data-particles-url attribute.
+(function () {
+  var reduce = window.matchMedia &&
+    window.matchMedia('(prefers-reduced-motion: reduce)').matches;
+
+  // Applies the container's reduced motion policy. Returns false when the
+  // container should not be rendered at all.
+  function applyPolicy(el, config) {
+    if (!reduce) {
+      return true;
+    }
+    switch (el.getAttribute('data-particles-reduced-motion')) {
+      case 'disable':
+        return false;
+      case 'ignore':
+        return true;
+      default:
+        if (config.particles && config.particles.move) {
+          config.particles.move.enable = false;
+        }
+        return true;
+    }
+  }
+
+  function render(id, config) {
+    var el = document.getElementById(id);
+    if (el && applyPolicy(el, config)) {
+      particlesJS(id, config);
+    }
+  }
+
+  function load(el) {
+    var xhr = new XMLHttpRequest();
+    xhr.open('GET', el.getAttribute('data-particles-url'));
+    xhr.onload = function () {
+      if (xhr.status === 200) {
+        render(el.id, JSON.parse(xhr.responseText));
+      }
+    };
+    xhr.send();
+  }
+
+  function start() {
+    var inline = document.querySelectorAll('script[data-particles-config]');
+    for (var i = 0; i < inline.length; i++) {
+      render(inline[i].getAttribute('data-particles-config'), JSON.parse(inline[i].textContent));
+    }
+
+    var remote = document.querySelectorAll('[data-particles-url]');
+    for (var j = 0; j < remote.length; j++) {
+      load(remote[j]);
+    }
+  }
+
+  if (document.readyState === 'loading') {
+    document.addEventListener('DOMContentLoaded', start);
+  } else {
+    start();
+  }
+})();
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/particles-go/particles"
+)
+
+// The pin is repeated in files that can't share the constant
+func TestRuntimeIntegrityPinned(t *testing.T) {
+	for _, name := range []string{"fetch_runtime.go", "demo.html"} {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), runtimeIntegrity) {
+			t.Errorf("%s doesn't pin %s", name, runtimeIntegrity)
+		}
+	}
+}
+
+func TestAssetServerRuntime(t *testing.T) {
+	assets, err := newAssetServer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Binaries built before go generate fetched the runtime don't have one
+	if runtime := assets.assets[runtimePath]; runtime != nil {
+		info, ok := assets.manifest.Lookup(runtimePath)
+		if !ok {
+			t.Fatal("embedded runtime not fingerprinted")
+		}
+		if err := particles.CheckIntegrity(info.Integrity, runtime.data); err != nil {
+			t.Error(err)
+		}
+	}
+}
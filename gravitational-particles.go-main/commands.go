@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+
+	"github.com/yourusername/particles-go/particles"
+)
+
+// loadPresets registers the presets of dir when it is set
+func loadPresets(dir string, stderr io.Writer) bool {
+	if dir == "" {
+		return true
+	}
+	if _, err := particles.LoadPresetDir(dir); err != nil {
+		fmt.Fprintf(stderr, "particles-go: %v\n", err)
+		return false
+	}
+	return true
+}
+
+// writeJSON writes v as indented JSON
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func runExport(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	out := flags.String("o", "data/particles.json", "path of the generated data file, - for stdout")
+	siteConfig := flags.String("site", "", "Hugo config.toml to read [params.particles] from")
+	content := flags.String("content", "", "Hugo content directory whose front matter to validate")
+	presetDir := flags.String("presets", envOr(envPresetDir, ""), "directory of <name>.json presets to include")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if !loadPresets(*presetDir, stderr) {
+		return exitFailure
+	}
+
+	site := particles.DefaultSiteParams()
+	if *siteConfig != "" {
+		var err error
+		if site, err = particles.LoadSiteParams(*siteConfig); err != nil {
+			fmt.Fprintf(stderr, "particles-go: %v\n", err)
+			return exitFailure
+		}
+	}
+
+	if *content != "" {
+		pages, err := particles.ScanContent(*content, site)
+		if err != nil {
+			fmt.Fprintf(stderr, "particles-go: %v\n", err)
+			return exitFailure
+		}
+		failed := false
+		for _, page := range pages {
+			if page.Err != nil {
+				fmt.Fprintf(stderr, "%s: %v\n", page.Path, page.Err)
+				failed = true
+			}
+		}
+		if failed {
+			return exitFailure
+		}
+	}
+
+	data := particles.BuildHugoData(site)
+	if *out == "-" {
+		bytes, err := data.ToJSON()
+		if err != nil {
+			fmt.Fprintf(stderr, "particles-go: %v\n", err)
+			return exitFailure
+		}
+		stdout.Write(bytes)
+		return exitOK
+	}
+
+	if err := data.WriteFile(*out); err != nil {
+		fmt.Fprintf(stderr, "particles-go: %v\n", err)
+		return exitFailure
+	}
+	fmt.Fprintf(stderr, "Wrote %s\n", *out)
+	return exitOK
+}
+
+func runRandom(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("random", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	seed := flags.Int64("seed", 0, "seed for a reproducible configuration (default: time-based)")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	config := particles.NewRandomConfig(rand.New(rand.NewSource(*seed)))
+
+	if err := writeJSON(stdout, config); err != nil {
+		fmt.Fprintf(stderr, "particles-go: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+func runPresets(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("presets", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	presetDir := flags.String("presets", envOr(envPresetDir, ""), "directory of <name>.json presets to include")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	// Flags may also follow the subcommand, as in "list -presets dir"
+	var words []string
+	for flags.NArg() > 0 {
+		words = append(words, flags.Arg(0))
+		if code, ok := parseFlags(flags, flags.Args()[1:]); !ok {
+			return code
+		}
+	}
+	if !loadPresets(*presetDir, stderr) {
+		return exitFailure
+	}
+
+	switch {
+	case len(words) == 1 && words[0] == "list":
+		for _, name := range particles.PresetNames() {
+			fmt.Fprintln(stdout, name)
+		}
+		return exitOK
+	case len(words) == 2 && words[0] == "show":
+		if !particles.IsPreset(words[1]) {
+			fmt.Fprintf(stderr, "particles-go: unknown preset %q\n", words[1])
+			return exitFailure
+		}
+		if err := writeJSON(stdout, particles.GetPreset(words[1])); err != nil {
+			fmt.Fprintf(stderr, "particles-go: %v\n", err)
+			return exitFailure
+		}
+		return exitOK
+	default:
+		fmt.Fprintln(stderr, "Usage: particles-go presets [-presets dir] list | show <name>")
+		return exitUsage
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/yourusername/particles-go/particles"
+)
+
+func runConvert(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	to := flags.String("to", particles.FormatTSParticles, "output format: particlesjs or tsparticles")
+	from := flags.String("from", "auto", "input format: auto, particlesjs or tsparticles")
+	out := flags.String("o", "-", "output file, - for stdout")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: particles-go convert [-from format] [-to format] [-o file] <file>")
+		flags.PrintDefaults()
+	}
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+	for _, format := range []string{*to, *from} {
+		switch format {
+		case "auto", particles.FormatParticlesJS, particles.FormatTSParticles:
+		default:
+			fmt.Fprintf(stderr, "particles-go: unknown format %q\n", format)
+			return exitUsage
+		}
+	}
+	if *to == "auto" {
+		fmt.Fprintln(stderr, "particles-go: -to must name a format")
+		return exitUsage
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "particles-go: %v\n", err)
+		return exitFailure
+	}
+
+	if *from == "auto" {
+		if *from, err = particles.DetectFormat(data); err != nil {
+			fmt.Fprintf(stderr, "particles-go: %s: %v\n", flags.Arg(0), err)
+			return exitFailure
+		}
+	}
+
+	// Everything converts through the particles.js Config
+	var config *particles.Config
+	switch *from {
+	case particles.FormatParticlesJS:
+		config, err = particles.DecodeConfig(data)
+	case particles.FormatTSParticles:
+		var options *particles.TSOptions
+		if options, err = particles.DecodeTSParticles(data); err == nil {
+			config = options.ToConfig()
+		}
+		if err == nil {
+			// Say what particles.js can't represent rather than lose it
+			// silently
+			var unknown []string
+			if unknown, err = particles.UnknownTSFields(data); err == nil && len(unknown) > 0 {
+				fmt.Fprintf(stderr, "particles-go: warning: %s: dropped settings particles.js has no equivalent for: %s\n",
+					flags.Arg(0), strings.Join(unknown, ", "))
+			}
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "particles-go: %s: %v\n", flags.Arg(0), err)
+		return exitFailure
+	}
+
+	var result interface{} = config
+	if *to == particles.FormatTSParticles {
+		result = config.ToTSParticles()
+	}
+
+	w := stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(stderr, "particles-go: %v\n", err)
+			return exitFailure
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := writeJSON(w, result); err != nil {
+		fmt.Fprintf(stderr, "particles-go: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
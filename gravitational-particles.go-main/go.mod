@@ -1,3 +1,3 @@
 module github.com/yourusername/particles-go
 
-go 1.18 
\ No newline at end of file
+go 1.21 
\ No newline at end of file
@@ -26,6 +26,7 @@ function updateBounceSettings(newBounceFactor, newCursorRadius) {
 // Expose functions to window
 window.updateBounceSettings = updateBounceSettings;
 window.applyBounceCursor = applyBounceCursor;
+window.loadGravityConfig = loadGravityConfig;
 
 // Function to toggle debug mode with keyboard
 function setupDebugToggle() {
@@ -50,14 +51,32 @@ function setupDebugToggle() {
     });
 }
 
+// Fetches the configuration served at url by the Go server. It resolves to
+// null when url is empty or the configuration can't be loaded, so that the
+// simulation starts from random orbits instead.
+function loadGravityConfig(url) {
+    if (!url) {
+        return Promise.resolve(null);
+    }
+    return fetch(url)
+        .then(function(response) {
+            return response.ok ? response.json() : null;
+        })
+        .catch(function() {
+            return null;
+        });
+}
+
 // NEW FUNCTION: Specifically for cursor bounce
-function applyBounceCursor() {
+function applyBounceCursor(config) {
     console.log('Starting gravity simulation WITH CURSOR BOUNCE');
     bounceEnabled = true;
-    applyPureGravity();
+    applyPureGravity(config);
 }
 
-function applyPureGravity() {
+// config is an optional configuration from the Go server; when its
+// gravity section carries a pre-warmed state, planets start from it
+function applyPureGravity(config) {
     // Check if we're applying with bounce or not
     const withBounce = bounceEnabled;
     console.log(`Applying pure gravity simulation ${withBounce ? 'WITH' : 'WITHOUT'} cursor bounce`);
@@ -160,8 +179,27 @@ function applyPureGravity() {
     // Create planets
     const planets = [];
     const colors = ['#ff7e7e', '#7eff8e', '#7ee0ff', '#ffffff'];
+    const state = config && config.gravity && config.gravity.state;
+    
+    // Start from the settled state the server simulated, positioned
+    // relative to the sun
+    if (state && Array.isArray(state.bodies)) {
+        state.bodies.forEach(function(body) {
+            planets.push({
+                x: sun.x + body.x,
+                y: sun.y + body.y,
+                radius: body.radius,
+                mass: body.mass,
+                color: body.color || colors[0],
+                vx: body.vx,
+                vy: body.vy,
+                flashTime: 0
+            });
+        });
+    }
     
-    for (let i = 0; i < 150; i++) {
+    const warmedUp = planets.length > 0;
+    for (let i = 0; !warmedUp && i < 150; i++) {
         const angle = Math.random() * Math.PI * 2;
         const distance = 100 + Math.random() * 150;
         const x = sun.x + Math.cos(angle) * distance;
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/yourusername/particles-go/particles"
+)
+
+// requestGuard protects the server from abusive clients: it limits the
+// request rate of each client IP and the size of request bodies
+type requestGuard struct {
+	// trustProxy takes client IPs from X-Forwarded-For
+	trustProxy bool
+	// allow lists the clients exempt from rate limiting
+	allow *particles.IPAllowList
+	// limiter limits the requests of each client; nil disables it
+	limiter *particles.TokenBucketLimiter
+	// maxBody is the largest request body accepted, in bytes; 0 disables it
+	maxBody int64
+}
+
+// unlimitedRoutes are never rate limited, so that load balancers don't
+// take the server out of rotation when it is busy
+var unlimitedRoutes = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+}
+
+// admit records the client IP of r in details and checks r against the
+// guard's limits. When r is rejected it writes the error response and
+// returns false.
+func (g *requestGuard) admit(w http.ResponseWriter, r *http.Request, route string, details *particles.RequestDetails) bool {
+	details.ClientIP = particles.ClientIP(r, g.trustProxy)
+
+	if g.limiter != nil && !unlimitedRoutes[route] && !g.allow.Contains(details.ClientIP) {
+		if !g.limiter.Allow(details.ClientIP) {
+			retry := g.limiter.RetryAfter(details.ClientIP)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+			particles.WriteError(w, r, http.StatusTooManyRequests, "Too many requests")
+			return false
+		}
+	}
+
+	if g.maxBody > 0 && r.Body != nil && r.Body != http.NoBody {
+		if r.ContentLength > g.maxBody {
+			particles.WriteError(w, r, http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("Request body larger than %d bytes", g.maxBody))
+			return false
+		}
+		// Bodies without a Content-Length fail once they are read past the limit
+		r.Body = http.MaxBytesReader(w, r.Body, g.maxBody)
+	}
+	return true
+}
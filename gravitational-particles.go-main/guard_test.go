@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/particles-go/particles"
+)
+
+func TestRequestGuard(t *testing.T) {
+	allow, err := particles.ParseIPAllowList("10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	guard := &requestGuard{
+		allow:   allow,
+		limiter: particles.NewTokenBucketLimiter(0.5, 1),
+		maxBody: 8,
+	}
+
+	admit := func(remote, route string, body io.Reader) (*httptest.ResponseRecorder, bool) {
+		r := httptest.NewRequest("POST", route, body)
+		r.RemoteAddr = remote + ":1234"
+		rec := httptest.NewRecorder()
+		return rec, guard.admit(rec, r, route, &particles.RequestDetails{})
+	}
+
+	if _, ok := admit("192.0.2.1", "/api", nil); !ok {
+		t.Fatal("first request rejected")
+	}
+	rec, ok := admit("192.0.2.1", "/api", nil)
+	if ok || rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "2" {
+		t.Errorf("over the limit: admitted=%v %d Retry-After %q", ok, rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if _, ok := admit("192.0.2.1", "/healthz", nil); !ok {
+		t.Error("probe rate limited")
+	}
+	for i := 0; i < 3; i++ {
+		if _, ok := admit("10.0.0.1", "/api", nil); !ok {
+			t.Error("allow-listed client rate limited")
+		}
+	}
+
+	rec, ok = admit("10.0.0.1", "/api", strings.NewReader("0123456789"))
+	if ok || rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large body: admitted=%v %d", ok, rec.Code)
+	}
+
+	// Without a Content-Length the body fails once read past the limit
+	r := httptest.NewRequest("POST", "/api", io.MultiReader(strings.NewReader("0123456789")))
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.ContentLength = -1
+	if !guard.admit(httptest.NewRecorder(), r, "/api", &particles.RequestDetails{}) {
+		t.Fatal("streamed body rejected up front")
+	}
+	if _, err := io.ReadAll(r.Body); err == nil {
+		t.Error("streamed body read past the limit")
+	}
+}
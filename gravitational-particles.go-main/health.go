@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"github.com/yourusername/particles-go/particles"
+)
+
+// version is set at build time with -ldflags "-X main.version=v1.2.3"
+var version = ""
+
+// buildInfo describes the running binary
+type buildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	GoVersion string `json:"go"`
+}
+
+// currentBuild returns the version set at link time, falling back to the
+// module version and VCS revision recorded by the Go toolchain
+func currentBuild() buildInfo {
+	info := buildInfo{Version: version, GoVersion: runtime.Version()}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for _, setting := range bi.Settings {
+			if setting.Key == "vcs.revision" {
+				info.Commit = setting.Value
+			}
+		}
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	return info
+}
+
+func runVersion(args []string, stdout, stderr io.Writer) int {
+	info := currentBuild()
+	fmt.Fprintf(stdout, "particles-go %s", info.Version)
+	if info.Commit != "" {
+		fmt.Fprintf(stdout, " (%s)", info.Commit)
+	}
+	fmt.Fprintf(stdout, " %s\n", info.GoVersion)
+	return exitOK
+}
+
+// readiness tracks whether the server can serve configurations: the
+// presets have loaded and the store is reachable
+type readiness struct {
+	mu         sync.RWMutex
+	loaded     bool
+	presetsErr error
+	store      particles.ConfigStore
+}
+
+// presetsLoaded records the outcome of loading the presets
+func (r *readiness) presetsLoaded(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loaded = true
+	r.presetsErr = err
+}
+
+// check returns the reasons the server is not ready, if any
+func (r *readiness) check() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var problems []string
+	switch {
+	case !r.loaded:
+		problems = append(problems, "presets: still loading")
+	case r.presetsErr != nil:
+		problems = append(problems, fmt.Sprintf("presets: %v", r.presetsErr))
+	}
+	if pinger, ok := r.store.(particles.Pinger); ok {
+		if err := pinger.Ping(); err != nil {
+			problems = append(problems, fmt.Sprintf("store: %v", err))
+		}
+	}
+	return problems
+}
+
+// registerHealth adds the probe endpoints to mux:
+//
+//	/healthz  the process is up
+//	/readyz   presets are loaded and the store is reachable
+//	/version  build information
+func registerHealth(mux *http.ServeMux, ready *readiness) {
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeStatus(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if problems := ready.check(); len(problems) > 0 {
+			writeStatus(w, http.StatusServiceUnavailable, map[string]interface{}{
+				"status":   "unavailable",
+				"problems": problems,
+			})
+			return
+		}
+		writeStatus(w, http.StatusOK, map[string]string{"status": "ready"})
+	})
+
+	mux.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+		writeStatus(w, http.StatusOK, currentBuild())
+	})
+}
+
+// writeStatus writes v as a JSON response with the given status code
+func writeStatus(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
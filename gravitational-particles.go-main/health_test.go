@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/particles-go/particles"
+)
+
+// pingStore is a store whose backend is reachable unless err is set
+type pingStore struct {
+	particles.ConfigStore
+	err error
+}
+
+func (s pingStore) Ping() error { return s.err }
+
+func TestReadiness(t *testing.T) {
+	tests := []struct {
+		desc     string
+		loaded   bool
+		presets  error
+		store    particles.ConfigStore
+		problems []string
+	}{
+		{"loading", false, nil, particles.NewMemoryStore(), []string{"presets: still loading"}},
+		{"ready", true, nil, particles.NewMemoryStore(), nil},
+		{"presets failed", true, errors.New("bad preset"), particles.NewMemoryStore(), []string{"presets: bad preset"}},
+		{"store down", true, nil, pingStore{err: errors.New("unreachable")}, []string{"store: unreachable"}},
+		{"store up", true, nil, pingStore{}, nil},
+	}
+	for _, tt := range tests {
+		ready := &readiness{store: tt.store}
+		if tt.loaded {
+			ready.presetsLoaded(tt.presets)
+		}
+		if got := ready.check(); strings.Join(got, "\n") != strings.Join(tt.problems, "\n") {
+			t.Errorf("%s: got %q, want %q", tt.desc, got, tt.problems)
+		}
+	}
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	ready := &readiness{store: particles.NewMemoryStore()}
+	mux := http.NewServeMux()
+	registerHealth(mux, ready)
+
+	get := func(path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		var body map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if rec.Header().Get("Cache-Control") != "no-store" {
+			t.Errorf("%s: cacheable response", path)
+		}
+		return rec, body
+	}
+
+	if rec, body := get("/healthz"); rec.Code != http.StatusOK || body["status"] != "ok" {
+		t.Errorf("/healthz: %d %v", rec.Code, body)
+	}
+	if rec, body := get("/readyz"); rec.Code != http.StatusServiceUnavailable || body["status"] != "unavailable" {
+		t.Errorf("/readyz while loading: %d %v", rec.Code, body)
+	}
+	ready.presetsLoaded(nil)
+	if rec, body := get("/readyz"); rec.Code != http.StatusOK || body["status"] != "ready" {
+		t.Errorf("/readyz: %d %v", rec.Code, body)
+	}
+	if rec, body := get("/version"); rec.Code != http.StatusOK || body["version"] == "" || body["go"] == "" {
+		t.Errorf("/version: %d %v", rec.Code, body)
+	}
+}
+
+func TestCurrentBuild(t *testing.T) {
+	defer func(v string) { version = v }(version)
+	version = "v1.2.3"
+	if got := currentBuild().Version; got != "v1.2.3" {
+		t.Errorf("got version %q, want the one set at link time", got)
+	}
+	version = ""
+	if got := currentBuild().Version; got == "" {
+		t.Error("no fallback version")
+	}
+}
@@ -1,48 +1,153 @@
+//go:generate go run . export -o data/particles.json
+
+// Command particles-go serves and manages particles.js configurations for
+// Hugo sites.
+//
+// Usage:
+//
+//	particles-go <command> [flags] [arguments]
+//
+// Run "particles-go help" for the list of commands.
 package main
 
 import (
-	"log"
-	"net/http"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
 
-	"github.com/yourusername/particles-go/particles" // Replace with your actual import path
+// Exit codes returned to scripts
+const (
+	exitOK      = 0 // Command succeeded
+	exitFailure = 1 // Command ran but failed, e.g. invalid configs
+	exitUsage   = 2 // Command line could not be parsed
 )
 
+// command is a particles-go subcommand
+type command struct {
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) int
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"serve":    {"Run the configuration server", runServe},
+		"export":   {"Write the Hugo module data file", runExport},
+		"validate": {"Check particles.js configuration files", runValidate},
+		"convert":  {"Convert between particles.js and tsParticles formats", runConvert},
+		"simulate": {"Run a gravity simulation headlessly and export trajectories", runSimulate},
+		"random":   {"Print a random configuration", runRandom},
+		"presets":  {"List presets or show one (presets list | presets show <name>)", runPresets},
+		"version":  {"Print version information", runVersion},
+		"help":     {"Show this help", runHelp},
+	}
+}
+
 func main() {
-	// Create a new particles handler for Hugo
-	// The first parameter is the endpoint URL for configurations
-	// The second parameter is the path to the particles.js file within your static directory
-	particlesHandler := particles.NewHugoHandler(
-		"/api/particles-config",
-		"/js/particles.min.js",
-	)
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
 
-	// Register the handler to serve particle configs
-	http.Handle("/api/particles-config", particlesHandler)
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "particles-go: unknown command %q\n\n", args[0])
+		usage(stderr)
+		return exitUsage
+	}
+	return cmd.run(args[1:], stdout, stderr)
+}
+
+func runHelp(args []string, stdout, stderr io.Writer) int {
+	usage(stdout)
+	return exitOK
+}
 
-	// Serve static files from the 'static' directory
-	fs := http.FileServer(http.Dir("static"))
-	http.Handle("/js/", fs)
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: particles-go <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
 
-	// Add more routes for your Hugo API here if needed
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].summary)
+	}
 
-	// Start the server
-	log.Println("Starting server on :8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run \"particles-go <command> -h\" for the flags of a command.")
+	fmt.Fprintln(w, "Flags default to these environment variables when set:")
+	for _, env := range envVars {
+		fmt.Fprintf(w, "  %-22s %s\n", env.name, env.usage)
+	}
 }
 
-// How to use with Hugo:
-//
-// 1. Add the particles.js library to your static directory:
-//    - Copy particles.min.js to static/js/
-//
-// 2. Create the Hugo shortcode in layouts/shortcodes/particles.html
-//
-// 3. Build and run this Go program alongside your Hugo site
-//
-// 4. Use the shortcode in your Hugo content:
-//    {{< particles >}} - Uses default config
-//    {{< particles preset="snow" >}} - Uses a snow preset
-//    {{< particles color="#ff0000" number="150" >}} - Customized
-//
-// Note: For production, you may want to integrate this with your
-// main Hugo site server using Go plugins or embedding Hugo as a library.
+// parseFlags parses a command's flags. When parsing stops the command, it
+// returns false and the exit code to return.
+func parseFlags(flags *flag.FlagSet, args []string) (int, bool) {
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK, false
+		}
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+// envVar documents an environment variable backing a flag default
+type envVar struct {
+	name  string
+	usage string
+}
+
+// Environment variables read by the commands
+const (
+	envAddr        = "PARTICLES_ADDR"
+	envEndpoint    = "PARTICLES_ENDPOINT"
+	envStaticDir   = "PARTICLES_STATIC_DIR"
+	envPresetDir   = "PARTICLES_PRESET_DIR"
+	envStore       = "PARTICLES_STORE"
+	envJsPath      = "PARTICLES_JS_PATH"
+	envFormat      = "PARTICLES_FORMAT"
+	envTLSCert     = "PARTICLES_TLS_CERT"
+	envTLSKey      = "PARTICLES_TLS_KEY"
+	envAllow       = "PARTICLES_ALLOW"
+	envAdminKeys   = "PARTICLES_ADMIN_KEYS"
+	envAuditLog    = "PARTICLES_AUDIT_LOG"
+	envCORSOrigins = "PARTICLES_CORS_ORIGINS"
+)
+
+var envVars = []envVar{
+	{envAddr, "address to listen on (-addr)"},
+	{envEndpoint, "configuration endpoint path (-endpoint)"},
+	{envStaticDir, "directory overriding the embedded assets (-static)"},
+	{envPresetDir, "directory of <name>.json presets (-presets)"},
+	{envStore, "store backend, memory, memory:<capacity> or file:<dir> (-store)"},
+	{envJsPath, "URL of particles.min.js (-js-path)"},
+	{envFormat, "format served by default, particlesjs or tsparticles (-format)"},
+	{envTLSCert, "TLS certificate file (-tls-cert)"},
+	{envTLSKey, "TLS private key file (-tls-key)"},
+	{envAllow, "IPs and CIDR networks exempt from rate limits (-allow)"},
+	{envAdminKeys, "key file enabling admin API writes (-admin-keys)"},
+	{envAuditLog, "file admin API changes are appended to (-audit-log)"},
+	{envCORSOrigins, "origins allowed to load configs (-cors-origins)"},
+}
+
+// envOr returns the environment variable's value, or def when it is unset
+func envOr(name, def string) string {
+	if v, ok := os.LookupEnv(name); ok {
+		return v
+	}
+	return def
+}
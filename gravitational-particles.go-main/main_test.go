@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/particles-go/particles"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{nil, exitUsage, "", "Usage: particles-go"},
+		{[]string{"nope"}, exitUsage, "", `unknown command "nope"`},
+		{[]string{"help"}, exitOK, "Commands:", ""},
+		{[]string{"version"}, exitOK, "particles-go ", ""},
+		{[]string{"presets", "list"}, exitOK, particles.PresetSnow + "\n", ""},
+		{[]string{"presets", "show", "nope"}, exitFailure, "", `unknown preset "nope"`},
+		{[]string{"presets"}, exitUsage, "", "Usage: particles-go presets"},
+		{[]string{"random", "-bogus"}, exitUsage, "", "flag provided but not defined"},
+		{[]string{"random", "-h"}, exitOK, "", "-seed"},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		code := run(tt.args, &stdout, &stderr)
+		if code != tt.code {
+			t.Errorf("%v: exit %d, want %d", tt.args, code, tt.code)
+		}
+		if !strings.Contains(stdout.String(), tt.stdout) || !strings.Contains(stderr.String(), tt.stderr) {
+			t.Errorf("%v: got stdout %q, stderr %q", tt.args, stdout.String(), stderr.String())
+		}
+	}
+}
+
+func TestRunPresetsFlagAfterSubcommand(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cmdtest.json"), []byte(`{"particles": {"number": {"value": 10}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args   []string
+		code   int
+		stdout string
+	}{
+		{[]string{"list", "-presets", dir}, exitOK, "cmdtest\n"},
+		{[]string{"show", "-presets", dir, "cmdtest"}, exitOK, `"value": 10`},
+		{[]string{"show", "cmdtest", "-presets", dir}, exitOK, `"value": 10`},
+		{[]string{"list", "-bogus"}, exitUsage, ""},
+		{[]string{"list", "extra"}, exitUsage, ""},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := runPresets(tt.args, &stdout, &stderr); code != tt.code {
+			t.Errorf("%v: exit %d, want %d: %s", tt.args, code, tt.code, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), tt.stdout) {
+			t.Errorf("%v: got %q, want %q", tt.args, stdout.String(), tt.stdout)
+		}
+	}
+}
+
+func TestRunRandomSeed(t *testing.T) {
+	var first, second bytes.Buffer
+	run([]string{"random", "-seed", "7"}, &first, &bytes.Buffer{})
+	run([]string{"random", "-seed", "7"}, &second, &bytes.Buffer{})
+	if first.String() != second.String() {
+		t.Error("the same seed gave different configurations")
+	}
+	if _, err := particles.DecodeConfig(first.Bytes()); err != nil {
+		t.Errorf("random configuration is invalid: %v", err)
+	}
+}
+
+func TestEnvOr(t *testing.T) {
+	t.Setenv("PARTICLES_TEST_SET", "value")
+	t.Setenv("PARTICLES_TEST_EMPTY", "")
+	if got := envOr("PARTICLES_TEST_SET", "def"); got != "value" {
+		t.Errorf("set: got %q", got)
+	}
+	if got := envOr("PARTICLES_TEST_EMPTY", "def"); got != "" {
+		t.Errorf("set to empty: got %q", got)
+	}
+	if got := envOr("PARTICLES_TEST_UNSET", "def"); got != "def" {
+		t.Errorf("unset: got %q", got)
+	}
+}
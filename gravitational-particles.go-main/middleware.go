@@ -0,0 +1,158 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/yourusername/particles-go/particles"
+)
+
+// statusRecorder captures the status code and size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// requestIDPattern limits the request IDs accepted from clients or proxies
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// newRequestID returns a random 128-bit request ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// serveMux wraps mux with the middleware every request goes through: it
+// assigns a request ID, applies guard's limits, recovers from panics,
+// answers unknown routes with a JSON error, records metrics and writes the
+// access log. Routes are labeled with the mux pattern that matched so that
+// arbitrary paths don't create new label values.
+func serveMux(mux *http.ServeMux, guard *requestGuard, metrics *particles.Metrics, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		// Reuse the proxy's request ID so logs can be correlated
+		requestID := r.Header.Get("X-Request-ID")
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		details := &particles.RequestDetails{RequestID: requestID}
+		r = r.WithContext(particles.ContextWithRequestDetails(r.Context(), details))
+		w.Header().Set("X-Request-ID", requestID)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		_, route := mux.Handler(r)
+
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					if err == http.ErrAbortHandler {
+						panic(err)
+					}
+					logger.Error("panic serving request",
+						"request_id", requestID,
+						"panic", fmt.Sprint(err),
+						"stack", string(debug.Stack()))
+					if !rec.wroteHeader {
+						particles.WriteError(rec, r, http.StatusInternalServerError, "Internal server error")
+					} else {
+						rec.status = http.StatusInternalServerError
+					}
+				}
+			}()
+
+			if route == "" {
+				route = "unmatched"
+			}
+			if !guard.admit(rec, r, route, details) {
+				return
+			}
+			if route == "unmatched" {
+				particles.WriteError(rec, r, http.StatusNotFound, "Not found")
+				return
+			}
+			mux.ServeHTTP(rec, r)
+		}()
+
+		duration := time.Since(start)
+		metrics.ObserveRequest(route, rec.status, duration)
+
+		attrs := []any{
+			"request_id", requestID,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", route,
+			"status", rec.status,
+			"bytes", rec.bytes,
+			"duration_ms", float64(duration.Microseconds()) / 1000,
+			"remote", r.RemoteAddr,
+			"client_ip", details.ClientIP,
+		}
+		if details.ConfigID != "" {
+			attrs = append(attrs, "config_id", details.ConfigID)
+		}
+		if details.Preset != "" {
+			attrs = append(attrs, "preset", details.Preset)
+		}
+		logger.Info("request", attrs...)
+	})
+}
+
+// jsonErrorWriter replaces the plain-text error bodies written by handlers
+// such as http.FileServer with the server's JSON error body
+type jsonErrorWriter struct {
+	http.ResponseWriter
+	r        *http.Request
+	replaced bool
+}
+
+func (w *jsonErrorWriter) WriteHeader(status int) {
+	if status >= 400 && strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		w.replaced = true
+		w.Header().Del("Content-Length")
+		particles.WriteError(w.ResponseWriter, w.r, status, http.StatusText(status))
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *jsonErrorWriter) Write(b []byte) (int, error) {
+	if w.replaced {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// jsonErrors makes h's error responses use the JSON error body
+func jsonErrors(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(&jsonErrorWriter{ResponseWriter: w, r: r}, r)
+	})
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/particles-go/particles"
+)
+
+func TestServeMuxMetrics(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/configs/", func(w http.ResponseWriter, r *http.Request) {})
+	metrics := particles.NewMetrics()
+	h := serveMux(mux, &requestGuard{}, metrics, discardLogger())
+
+	for _, path := range []string{"/configs/a", "/configs/b", "/random-1", "/random-2"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	var b bytes.Buffer
+	metrics.WritePrometheus(&b)
+	for _, want := range []string{
+		`particles_http_requests_total{route="/configs/",status="200"} 2`,
+		`particles_http_requests_total{route="unmatched",status="404"} 2`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+	if strings.Contains(b.String(), "random-1") {
+		t.Error("request path used as a label")
+	}
+}
+
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) particles.ErrorDetail {
+	t.Helper()
+	var body particles.ErrorBody
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("error body %q: %v", rec.Body.String(), err)
+	}
+	return body.Error
+}
+
+func TestServeMuxRequestID(t *testing.T) {
+	mux := http.NewServeMux()
+	h := serveMux(mux, &requestGuard{}, nil, discardLogger())
+
+	tests := []struct {
+		header string
+		reused bool
+	}{
+		{"", false},
+		{"proxy-id.42", true},
+		{"bad id\r\n", false},
+		{strings.Repeat("a", 65), false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/missing", nil)
+		if tt.header != "" {
+			r.Header.Set("X-Request-ID", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+
+		id := rec.Header().Get("X-Request-ID")
+		if reused := id == tt.header; reused != tt.reused {
+			t.Errorf("%q: got request ID %q", tt.header, id)
+		}
+		if !requestIDPattern.MatchString(id) {
+			t.Errorf("%q: invalid request ID %q", tt.header, id)
+		}
+		detail := decodeError(t, rec)
+		if rec.Code != http.StatusNotFound || detail.Status != http.StatusNotFound || detail.RequestID != id {
+			t.Errorf("%q: got %d %+v", tt.header, rec.Code, detail)
+		}
+	}
+}
+
+func TestServeMuxPanic(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+	var logs bytes.Buffer
+	h := serveMux(mux, &requestGuard{}, nil, slog.New(slog.NewJSONHandler(&logs, nil)))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/panic", nil))
+	if detail := decodeError(t, rec); rec.Code != http.StatusInternalServerError || detail.Message != "Internal server error" {
+		t.Errorf("got %d %+v", rec.Code, detail)
+	}
+	if strings.Contains(rec.Body.String(), "boom") {
+		t.Error("panic value leaked to the client")
+	}
+	for _, want := range []string{`"msg":"panic serving request"`, `"panic":"boom"`, `"msg":"request"`, `"status":500`} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("log missing %s", want)
+		}
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	h := jsonErrors(http.FileServer(http.Dir(t.TempDir())))
+	r := httptest.NewRequest("GET", "/missing.js", nil)
+	r = r.WithContext(particles.ContextWithRequestDetails(r.Context(), &particles.RequestDetails{RequestID: "abc"}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("got content type %q", ct)
+	}
+	if detail := decodeError(t, rec); rec.Code != http.StatusNotFound || detail.Message != "Not Found" || detail.RequestID != "abc" {
+		t.Errorf("got %d %+v", rec.Code, detail)
+	}
+}
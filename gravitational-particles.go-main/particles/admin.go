@@ -0,0 +1,275 @@
+package particles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AdminHandler serves the configuration and preset API:
+//
+//	GET    <prefix>/configs/<id>     the stored configuration
+//	PUT    <prefix>/configs/<id>     store a configuration
+//	DELETE <prefix>/configs/<id>     delete a stored configuration
+//	GET    <prefix>/presets          the preset names
+//	GET    <prefix>/presets/<name>   a preset
+//	PUT    <prefix>/presets/<name>   add or replace a custom preset
+//	DELETE <prefix>/presets/<name>   delete a custom preset
+//
+// Reads are public. Writes need a key from Keys and are recorded in Audit.
+type AdminHandler struct {
+	Prefix string
+	Store  ConfigStore
+	// Keys authenticates writes; without keys every write is refused
+	Keys *KeyRing
+	// Audit records who changed which config or preset and when
+	Audit *AuditLog
+	// PresetDir, when set, is where written presets are saved so that
+	// they survive restarts
+	PresetDir string
+}
+
+// NewAdminHandler creates an admin API serving the configurations in store
+// under /api
+func NewAdminHandler(store ConfigStore) *AdminHandler {
+	return &AdminHandler{Prefix: "/api", Store: store}
+}
+
+// ServeHTTP routes admin API requests
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, h.Prefix)
+	switch {
+	case path == "/presets" || path == "/presets/":
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			methodNotAllowed(w, r, "GET, HEAD")
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, map[string][]string{"presets": PresetNames()})
+	case strings.HasPrefix(path, "/presets/"):
+		h.servePreset(w, r, strings.TrimPrefix(path, "/presets/"))
+	case strings.HasPrefix(path, "/configs/"):
+		h.serveConfig(w, r, strings.TrimPrefix(path, "/configs/"))
+	default:
+		WriteError(w, r, http.StatusNotFound, "Not found")
+	}
+}
+
+func (h *AdminHandler) serveConfig(w http.ResponseWriter, r *http.Request, id string) {
+	if !ValidConfigID(id) {
+		WriteError(w, r, http.StatusBadRequest, "Invalid config ID")
+		return
+	}
+	details := RequestDetailsFrom(r.Context())
+	details.ConfigID = id
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		config, exists, err := h.Store.Get(id)
+		if err != nil {
+			slog.Error("error loading config", "request_id", details.RequestID, "config_id", id, "error", err)
+			WriteError(w, r, http.StatusInternalServerError, "Error loading config")
+			return
+		}
+		if !exists {
+			WriteError(w, r, http.StatusNotFound, "Config not found")
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, config)
+
+	case http.MethodPut:
+		actor, body, ok := h.authorize(w, r)
+		if !ok {
+			return
+		}
+		config, err := DecodeConfig(body)
+		if err != nil {
+			WriteError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid config: %v", err))
+			return
+		}
+		// Warm up once here, so that serving the config never has to
+		config = config.WarmUp()
+		_, existed, err := h.Store.Get(id)
+		if err == nil {
+			err = h.Store.Put(id, config)
+		}
+		if err != nil {
+			slog.Error("error storing config", "request_id", details.RequestID, "config_id", id, "error", err)
+			WriteError(w, r, http.StatusInternalServerError, "Error storing config")
+			return
+		}
+		h.record(r, actor, AuditPut, AuditConfig, id)
+		writeAdminJSON(w, createdStatus(existed), config)
+
+	case http.MethodDelete:
+		deleter, ok := h.Store.(Deleter)
+		if !ok {
+			WriteError(w, r, http.StatusNotImplemented, "Store does not support deleting configs")
+			return
+		}
+		actor, _, ok := h.authorize(w, r)
+		if !ok {
+			return
+		}
+		deleted, err := deleter.Delete(id)
+		if err != nil {
+			slog.Error("error deleting config", "request_id", details.RequestID, "config_id", id, "error", err)
+			WriteError(w, r, http.StatusInternalServerError, "Error deleting config")
+			return
+		}
+		if !deleted {
+			WriteError(w, r, http.StatusNotFound, "Config not found")
+			return
+		}
+		h.record(r, actor, AuditDelete, AuditConfig, id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		methodNotAllowed(w, r, "GET, HEAD, PUT, DELETE")
+	}
+}
+
+func (h *AdminHandler) servePreset(w http.ResponseWriter, r *http.Request, name string) {
+	if !ValidConfigID(name) {
+		WriteError(w, r, http.StatusBadRequest, "Invalid preset name")
+		return
+	}
+	details := RequestDetailsFrom(r.Context())
+	details.Preset = name
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if !IsPreset(name) {
+			WriteError(w, r, http.StatusNotFound, "Preset not found")
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, GetPreset(name))
+
+	case http.MethodPut:
+		actor, body, ok := h.authorize(w, r)
+		if !ok {
+			return
+		}
+		config, err := DecodeConfig(body)
+		if err != nil {
+			WriteError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid config: %v", err))
+			return
+		}
+		// Save the file first so that a failed write changes nothing
+		if h.PresetDir != "" {
+			if err := SavePreset(h.PresetDir, name, config); err != nil {
+				slog.Error("error saving preset", "request_id", details.RequestID, "preset", name, "error", err)
+				WriteError(w, r, http.StatusInternalServerError, "Error saving preset")
+				return
+			}
+		}
+		_, existed := customPreset(name)
+		if err := RegisterPreset(name, config); err != nil {
+			slog.Error("error registering preset", "request_id", details.RequestID, "preset", name, "error", err)
+			WriteError(w, r, http.StatusInternalServerError, "Error registering preset")
+			return
+		}
+		h.record(r, actor, AuditPut, AuditPreset, name)
+		writeAdminJSON(w, createdStatus(existed), config)
+
+	case http.MethodDelete:
+		actor, _, ok := h.authorize(w, r)
+		if !ok {
+			return
+		}
+		if _, custom := customPreset(name); !custom {
+			if IsPreset(name) {
+				WriteError(w, r, http.StatusConflict, "Built-in presets cannot be deleted")
+			} else {
+				WriteError(w, r, http.StatusNotFound, "Preset not found")
+			}
+			return
+		}
+		if h.PresetDir != "" {
+			if err := RemovePreset(h.PresetDir, name); err != nil {
+				slog.Error("error deleting preset", "request_id", details.RequestID, "preset", name, "error", err)
+				WriteError(w, r, http.StatusInternalServerError, "Error deleting preset")
+				return
+			}
+		}
+		UnregisterPreset(name)
+		h.record(r, actor, AuditDelete, AuditPreset, name)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		methodNotAllowed(w, r, "GET, HEAD, PUT, DELETE")
+	}
+}
+
+// authorize reads the body of a write request and authenticates it. It
+// returns the name of the key that made the request, or writes the error
+// response and returns false.
+func (h *AdminHandler) authorize(w http.ResponseWriter, r *http.Request) (string, []byte, bool) {
+	if h.Keys == nil {
+		WriteError(w, r, http.StatusForbidden, "Admin API is disabled")
+		return "", nil, false
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			WriteError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body larger than %d bytes", tooLarge.Limit))
+		} else {
+			WriteError(w, r, http.StatusBadRequest, "Error reading request body")
+		}
+		return "", nil, false
+	}
+
+	actor, err := h.Keys.Authenticate(r, body)
+	if err != nil {
+		details := RequestDetailsFrom(r.Context())
+		slog.Warn("admin authentication failed", "request_id", details.RequestID, "client_ip", details.ClientIP, "error", err)
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf("Bearer, %s", HMACScheme))
+		WriteError(w, r, http.StatusUnauthorized, "Unauthorized")
+		return "", nil, false
+	}
+	return actor, body, true
+}
+
+// record writes an audit entry for a change and logs it
+func (h *AdminHandler) record(r *http.Request, actor, action, kind, id string) {
+	details := RequestDetailsFrom(r.Context())
+	entry := AuditEntry{
+		Time:      time.Now().UTC(),
+		Actor:     actor,
+		Action:    action,
+		Kind:      kind,
+		ID:        id,
+		RequestID: details.RequestID,
+		ClientIP:  details.ClientIP,
+	}
+
+	slog.Info("audit", "actor", actor, "action", action, "kind", kind, "id", id, "request_id", details.RequestID)
+	if err := h.Audit.Record(entry); err != nil {
+		slog.Error("error recording audit entry", "request_id", details.RequestID, "error", err)
+	}
+}
+
+// createdStatus is the status of a PUT response
+func createdStatus(existed bool) int {
+	if existed {
+		return http.StatusOK
+	}
+	return http.StatusCreated
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request, allow string) {
+	w.Header().Set("Allow", allow)
+	WriteError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,220 @@
+package particles
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HMACScheme is the Authorization scheme of signed requests
+const HMACScheme = "PARTICLES-HMAC-SHA256"
+
+// MaxClockSkew is how far the timestamp of a signed request may be from
+// the server's clock
+const MaxClockSkew = 5 * time.Minute
+
+// minSecretLength is the shortest secret accepted in a key file
+const minSecretLength = 16
+
+// ErrUnauthorized is returned for requests without valid credentials
+var ErrUnauthorized = errors.New("missing or invalid credentials")
+
+// APIKey is a credential for the admin API. Name identifies the key in the
+// audit log and in signed requests; Secret is never logged.
+type APIKey struct {
+	Name   string `json:"name"`
+	Secret string `json:"secret"`
+}
+
+// KeyRing holds the keys allowed to use the admin API
+type KeyRing struct {
+	keys []APIKey
+	now  func() time.Time
+
+	// seen holds the signatures of recent signed requests, so that a
+	// captured request can't be replayed while its timestamp is valid
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// keyFile is the layout of a key file
+type keyFile struct {
+	Keys []APIKey `json:"keys"`
+}
+
+// LoadKeyRing reads a key file: a JSON object with a "keys" list of
+// {"name", "secret"} objects
+func LoadKeyRing(path string) (*KeyRing, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key file: %v", err)
+	}
+	return ParseKeyRing(data)
+}
+
+// ParseKeyRing parses the contents of a key file
+func ParseKeyRing(data []byte) (*KeyRing, error) {
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing key file: %v", err)
+	}
+	if len(file.Keys) == 0 {
+		return nil, fmt.Errorf("key file has no keys")
+	}
+
+	names := make(map[string]bool, len(file.Keys))
+	for i, key := range file.Keys {
+		switch {
+		case key.Name == "":
+			return nil, fmt.Errorf("key %d has no name", i)
+		case names[key.Name]:
+			return nil, fmt.Errorf("duplicate key name %q", key.Name)
+		case len(key.Secret) < minSecretLength:
+			return nil, fmt.Errorf("secret of key %q must be at least %d characters", key.Name, minSecretLength)
+		}
+		names[key.Name] = true
+	}
+
+	return NewKeyRing(file.Keys...), nil
+}
+
+// NewKeyRing creates a key ring holding keys
+func NewKeyRing(keys ...APIKey) *KeyRing {
+	return &KeyRing{
+		keys: keys,
+		now:  time.Now,
+		seen: make(map[string]time.Time),
+	}
+}
+
+// Authenticate checks the credentials of r, whose body has already been
+// read into body, and returns the name of the key that made it. Two
+// schemes are accepted:
+//
+//	Authorization: Bearer <secret>
+//	Authorization: PARTICLES-HMAC-SHA256 key=<name>, timestamp=<unix seconds>, signature=<hex>
+//
+// See SignRequest for how signatures are computed.
+func (k *KeyRing) Authenticate(r *http.Request, body []byte) (string, error) {
+	scheme, credentials, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	switch {
+	case strings.EqualFold(scheme, "Bearer"):
+		return k.authenticateSecret(strings.TrimSpace(credentials))
+	case strings.EqualFold(scheme, HMACScheme):
+		return k.authenticateSignature(r, body, credentials)
+	default:
+		return "", ErrUnauthorized
+	}
+}
+
+func (k *KeyRing) authenticateSecret(secret string) (string, error) {
+	// Compare fixed-length digests so timing reveals neither the key length
+	// nor, as every key is compared, which key matched
+	given := sha256.Sum256([]byte(secret))
+	name := ""
+	for _, key := range k.keys {
+		want := sha256.Sum256([]byte(key.Secret))
+		if subtle.ConstantTimeCompare(want[:], given[:]) == 1 {
+			name = key.Name
+		}
+	}
+	if name == "" {
+		return "", ErrUnauthorized
+	}
+	return name, nil
+}
+
+func (k *KeyRing) authenticateSignature(r *http.Request, body []byte, credentials string) (string, error) {
+	fields := make(map[string]string)
+	for _, part := range strings.Split(credentials, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return "", ErrUnauthorized
+		}
+		fields[name] = value
+	}
+
+	key, ok := k.key(fields["key"])
+	if !ok {
+		return "", ErrUnauthorized
+	}
+
+	unix, err := strconv.ParseInt(fields["timestamp"], 10, 64)
+	if err != nil {
+		return "", ErrUnauthorized
+	}
+	now := k.now()
+	timestamp := time.Unix(unix, 0)
+	if timestamp.Before(now.Add(-MaxClockSkew)) || timestamp.After(now.Add(MaxClockSkew)) {
+		return "", fmt.Errorf("request timestamp outside the allowed %v clock skew", MaxClockSkew)
+	}
+
+	signature, err := hex.DecodeString(fields["signature"])
+	if err != nil {
+		return "", ErrUnauthorized
+	}
+	expected := signature256(key.Secret, r.Method, r.URL.RequestURI(), unix, body)
+	if !hmac.Equal(signature, expected) {
+		return "", ErrUnauthorized
+	}
+
+	// Hex decoding ignores case, so a replay could change it
+	if !k.firstUse(hex.EncodeToString(signature), now) {
+		return "", fmt.Errorf("signed request was already used")
+	}
+	return key.Name, nil
+}
+
+// firstUse records a signature and reports whether it is new. Signatures
+// are forgotten once their timestamp can no longer be valid.
+func (k *KeyRing) firstUse(signature string, now time.Time) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for sig, at := range k.seen {
+		if now.Sub(at) > 2*MaxClockSkew {
+			delete(k.seen, sig)
+		}
+	}
+	if _, ok := k.seen[signature]; ok {
+		return false
+	}
+	k.seen[signature] = now
+	return true
+}
+
+func (k *KeyRing) key(name string) (APIKey, bool) {
+	for _, key := range k.keys {
+		if key.Name == name {
+			return key, true
+		}
+	}
+	return APIKey{}, false
+}
+
+// SignRequest signs r with key for the PARTICLES-HMAC-SHA256 scheme. The
+// signature is the hex HMAC-SHA256, keyed with the secret, of
+//
+//	<method>\n<request URI>\n<unix timestamp>\n<hex SHA-256 of the body>
+func SignRequest(r *http.Request, key APIKey, body []byte, t time.Time) {
+	signature := signature256(key.Secret, r.Method, r.URL.RequestURI(), t.Unix(), body)
+	r.Header.Set("Authorization", fmt.Sprintf("%s key=%s, timestamp=%d, signature=%s",
+		HMACScheme, key.Name, t.Unix(), hex.EncodeToString(signature)))
+}
+
+func signature256(secret, method, uri string, timestamp int64, body []byte) []byte {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n%s\n%d\n%s", method, uri, timestamp, hex.EncodeToString(bodyHash[:]))
+	return mac.Sum(nil)
+}
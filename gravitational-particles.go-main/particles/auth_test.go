@@ -0,0 +1,119 @@
+package particles
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testKey = APIKey{Name: "deploy", Secret: "0123456789abcdef0123"}
+
+func testKeyRing(now time.Time) *KeyRing {
+	keys := NewKeyRing(testKey, APIKey{Name: "other", Secret: "fedcba9876543210fedc"})
+	keys.now = func() time.Time { return now }
+	return keys
+}
+
+func TestAuthenticateBearer(t *testing.T) {
+	keys := testKeyRing(time.Now())
+	tests := []struct {
+		header string
+		name   string
+		ok     bool
+	}{
+		{"Bearer 0123456789abcdef0123", "deploy", true},
+		{"bearer fedcba9876543210fedc", "other", true},
+		{"Bearer wrong", "", false},
+		{"Bearer ", "", false},
+		{"Basic 0123456789abcdef0123", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("PUT", "/api/configs/a", nil)
+		r.Header.Set("Authorization", tt.header)
+		name, err := keys.Authenticate(r, nil)
+		if (err == nil) != tt.ok || name != tt.name {
+			t.Errorf("%q: got %q, %v; want %q, ok %v", tt.header, name, err, tt.name, tt.ok)
+		}
+	}
+}
+
+func TestAuthenticateSignature(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"particles":{}}`)
+
+	tests := []struct {
+		desc   string
+		signAt time.Time
+		key    APIKey
+		body   []byte
+		ok     bool
+	}{
+		{"valid", now, testKey, body, true},
+		{"within skew", now.Add(-MaxClockSkew + time.Second), testKey, body, true},
+		{"too old", now.Add(-MaxClockSkew - time.Second), testKey, body, false},
+		{"in the future", now.Add(MaxClockSkew + time.Second), testKey, body, false},
+		{"wrong secret", now, APIKey{Name: "deploy", Secret: "not the secret at all"}, body, false},
+		{"unknown key", now, APIKey{Name: "nobody", Secret: testKey.Secret}, body, false},
+		{"body changed", now, testKey, []byte(`{}`), false},
+	}
+	for _, tt := range tests {
+		keys := testKeyRing(now)
+		r := httptest.NewRequest("PUT", "/api/configs/a?x=1", nil)
+		SignRequest(r, tt.key, body, tt.signAt)
+		name, err := keys.Authenticate(r, tt.body)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: got error %v, want ok %v", tt.desc, err, tt.ok)
+		}
+		if tt.ok && name != testKey.Name {
+			t.Errorf("%s: got key %q", tt.desc, name)
+		}
+	}
+}
+
+func TestAuthenticateSignatureMalformed(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	for _, credentials := range []string{
+		"key=deploy",
+		"key=deploy, timestamp=abc, signature=00",
+		"key=deploy, timestamp=1700000000, signature=zz",
+		"key=deploy timestamp=1700000000",
+	} {
+		r := httptest.NewRequest("DELETE", "/api/configs/a", nil)
+		r.Header.Set("Authorization", HMACScheme+" "+credentials)
+		if _, err := testKeyRing(now).Authenticate(r, nil); err == nil {
+			t.Errorf("%q: accepted", credentials)
+		}
+	}
+}
+
+func TestAuthenticateSignatureReplay(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	keys := testKeyRing(now)
+	r := httptest.NewRequest("DELETE", "/api/presets/old", nil)
+	SignRequest(r, testKey, nil, now)
+	header := r.Header.Get("Authorization")
+
+	if _, err := keys.Authenticate(r, nil); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if _, err := keys.Authenticate(r, nil); err == nil {
+		t.Error("replayed request accepted")
+	}
+
+	// Hex is case-insensitive, so the same signature in upper case must
+	// be recognized too
+	prefix, signature, _ := strings.Cut(header, "signature=")
+	r.Header.Set("Authorization", prefix+"signature="+strings.ToUpper(signature))
+	if _, err := keys.Authenticate(r, nil); err == nil {
+		t.Error("replay with upper-case signature accepted")
+	}
+
+	// Signatures are forgotten once their timestamp has expired
+	keys.now = func() time.Time { return now.Add(3 * MaxClockSkew) }
+	keys.firstUse("unrelated", keys.now())
+	if len(keys.seen) != 1 {
+		t.Errorf("expired signatures kept: %d", len(keys.seen))
+	}
+}
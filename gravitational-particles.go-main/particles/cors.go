@@ -0,0 +1,138 @@
+package particles
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DevOrigins are the origins of the development servers: hugo server,
+// particles-go serve and the Node demo server
+var DevOrigins = []string{
+	"http://localhost:1313",
+	"http://127.0.0.1:1313",
+	"http://localhost:8080",
+	"http://127.0.0.1:8080",
+	"http://localhost:3000",
+	"http://127.0.0.1:3000",
+}
+
+// CORSPolicy lets pages on other origins fetch configurations, e.g. a
+// Hugo site calling particlesJS.load against a separate config server
+type CORSPolicy struct {
+	// AllowedOrigins lists the origins allowed to make requests, as
+	// scheme://host[:port]. "*" allows every origin.
+	AllowedOrigins []string
+	// AllowedMethods lists the methods allowed in preflighted requests
+	AllowedMethods []string
+	// AllowedHeaders lists the request headers allowed in preflighted
+	// requests
+	AllowedHeaders []string
+	// MaxAge is how long browsers may cache a preflight response; 0 leaves
+	// it to the browser
+	MaxAge time.Duration
+}
+
+// NewCORSPolicy creates a policy allowing origins to make GET requests
+func NewCORSPolicy(origins ...string) *CORSPolicy {
+	return &CORSPolicy{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{http.MethodGet, http.MethodHead},
+		AllowedHeaders: []string{"Content-Type", "X-Request-ID"},
+		MaxAge:         10 * time.Minute,
+	}
+}
+
+// ParseOrigins splits a comma separated list of origins. "dev" stands for
+// DevOrigins.
+func ParseOrigins(list string) []string {
+	var origins []string
+	for _, origin := range strings.Split(list, ",") {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		switch origin {
+		case "":
+		case "dev":
+			origins = append(origins, DevOrigins...)
+		default:
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// AllowsOrigin reports whether requests from origin are allowed
+func (p *CORSPolicy) AllowsOrigin(origin string) bool {
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle sets the CORS headers for r and reports whether r was a preflight
+// request, which it answers. A nil policy sets no headers.
+func (p *CORSPolicy) Handle(w http.ResponseWriter, r *http.Request) bool {
+	if p == nil {
+		return false
+	}
+
+	// The response depends on Origin unless every origin gets the same one
+	if !p.allowsAll() {
+		w.Header().Add("Vary", "Origin")
+	}
+
+	origin := r.Header.Get("Origin")
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+	if preflight {
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+	}
+	if origin == "" || !p.AllowsOrigin(origin) {
+		if preflight {
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return preflight
+	}
+
+	if p.allowsAll() {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+
+	if !preflight {
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
+		return false
+	}
+
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(p.AllowedMethods, ", "))
+	if len(p.AllowedHeaders) > 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(p.AllowedHeaders, ", "))
+	}
+	if p.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge.Seconds())))
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// Wrap applies the policy to every request to h
+func (p *CORSPolicy) Wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p.Handle(w, r) {
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (p *CORSPolicy) allowsAll() bool {
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package particles
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCORSPreflight(t *testing.T) {
+	policy := NewCORSPolicy("https://example.com")
+	policy.MaxAge = 90 * time.Second
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://example.com", true},
+		{"HTTPS://EXAMPLE.COM", true},
+		{"https://evil.example", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodOptions, "/api/particles-config", nil)
+		r.Header.Set("Access-Control-Request-Method", "GET")
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		rec := httptest.NewRecorder()
+		if !policy.Handle(rec, r) {
+			t.Errorf("%q: preflight not answered", tt.origin)
+		}
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("%q: got status %d", tt.origin, rec.Code)
+		}
+		h := rec.Header()
+		want := ""
+		if tt.allowed {
+			want = tt.origin
+		}
+		if got := h.Get("Access-Control-Allow-Origin"); got != want {
+			t.Errorf("%q: got Access-Control-Allow-Origin %q, want %q", tt.origin, got, want)
+		}
+		if !tt.allowed {
+			continue
+		}
+		if got := h.Get("Access-Control-Allow-Methods"); got != "GET, HEAD" {
+			t.Errorf("%q: got methods %q", tt.origin, got)
+		}
+		if got := h.Get("Access-Control-Allow-Headers"); got != "Content-Type, X-Request-ID" {
+			t.Errorf("%q: got headers %q", tt.origin, got)
+		}
+		if got := h.Get("Access-Control-Max-Age"); got != "90" {
+			t.Errorf("%q: got Max-Age %q", tt.origin, got)
+		}
+		if vary := strings.Join(h.Values("Vary"), ","); vary != "Origin,Access-Control-Request-Method,Access-Control-Request-Headers" {
+			t.Errorf("%q: got Vary %q", tt.origin, vary)
+		}
+	}
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	policy := NewCORSPolicy("*")
+	policy.MaxAge = 0
+
+	r := httptest.NewRequest(http.MethodOptions, "/", nil)
+	r.Header.Set("Origin", "https://anywhere.example")
+	r.Header.Set("Access-Control-Request-Method", "GET")
+	rec := httptest.NewRecorder()
+	policy.Handle(rec, r)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("got Access-Control-Allow-Origin %q, want *", got)
+	}
+	if rec.Header().Get("Access-Control-Max-Age") != "" {
+		t.Error("Max-Age set with MaxAge 0")
+	}
+	for _, v := range rec.Header().Values("Vary") {
+		if v == "Origin" {
+			t.Error("Vary: Origin set for a response that doesn't depend on it")
+		}
+	}
+}
+
+func TestCORSWrap(t *testing.T) {
+	called := 0
+	h := NewCORSPolicy("https://example.com").Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called++
+	}))
+
+	// Simple requests reach the handler with the headers set
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Origin", "https://example.com")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if called != 1 || rec.Header().Get("Access-Control-Expose-Headers") != "X-Request-ID" {
+		t.Errorf("simple request: called %d times, headers %v", called, rec.Header())
+	}
+
+	// Preflights don't
+	r = httptest.NewRequest(http.MethodOptions, "/", nil)
+	r.Header.Set("Origin", "https://example.com")
+	r.Header.Set("Access-Control-Request-Method", "GET")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if called != 1 {
+		t.Error("preflight reached the handler")
+	}
+
+	// Plain OPTIONS requests aren't preflights
+	r = httptest.NewRequest(http.MethodOptions, "/", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if called != 2 {
+		t.Error("OPTIONS request without Access-Control-Request-Method answered as a preflight")
+	}
+
+	var none *CORSPolicy
+	if none.Handle(httptest.NewRecorder(), r) {
+		t.Error("nil policy answered a request")
+	}
+}
+
+func TestParseOrigins(t *testing.T) {
+	got := ParseOrigins(" https://a.example/ ,, dev")
+	if len(got) != 1+len(DevOrigins) || got[0] != "https://a.example" || got[1] != DevOrigins[0] {
+		t.Errorf("got %q", got)
+	}
+	if got := ParseOrigins(""); len(got) != 0 {
+		t.Errorf("got %q for no origins", got)
+	}
+}
@@ -0,0 +1,185 @@
+package particles
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DecodeConfig decodes a particles.js JSON configuration and validates it.
+// Unlike json.Unmarshal it reports every unknown field, mistyped value and
+// invalid setting as ValidationErrors, each with the path of the value.
+func DecodeConfig(data []byte) (*Config, error) {
+	var raw interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return nil, ValidationErrors{{Message: syntaxMessage(data, err)}}
+	}
+	if dec.More() {
+		return nil, ValidationErrors{{Message: "unexpected data after the configuration"}}
+	}
+
+	var errs ValidationErrors
+	checkJSONType("", raw, reflect.TypeOf(Config{}), &errs)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, ValidationErrors{{Message: err.Error()}}
+	}
+	if err := config.Validate(); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// syntaxMessage describes a JSON syntax error with its line and column
+func syntaxMessage(data []byte, err error) string {
+	syntax, ok := err.(*json.SyntaxError)
+	if !ok {
+		return fmt.Sprintf("invalid JSON: %v", err)
+	}
+
+	line, col := 1, 1
+	for _, b := range data[:syntax.Offset] {
+		if b == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return fmt.Sprintf("invalid JSON at line %d, column %d: %v", line, col, err)
+}
+
+// checkJSONType compares a decoded JSON value with the Go type it will be
+// unmarshaled into, recording unknown fields and type mismatches
+func checkJSONType(path string, value interface{}, t reflect.Type, errs *ValidationErrors) {
+	if value == nil {
+		return
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	mismatch := func(want string) {
+		*errs = append(*errs, ValidationError{
+			Path:    path,
+			Message: fmt.Sprintf("expected %s, got %s", want, jsonTypeName(value)),
+		})
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return
+	case reflect.Struct:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			mismatch("an object")
+			return
+		}
+		fields := jsonFields(t)
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			field, ok := fields[key]
+			if !ok {
+				*errs = append(*errs, ValidationError{Path: joinPath(path, key), Message: "unknown field"})
+				continue
+			}
+			checkJSONType(joinPath(path, key), obj[key], field.Type, errs)
+		}
+	case reflect.Map:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			mismatch("an object")
+			return
+		}
+		for key, item := range obj {
+			checkJSONType(joinPath(path, key), item, t.Elem(), errs)
+		}
+	case reflect.Slice:
+		list, ok := value.([]interface{})
+		if !ok {
+			mismatch("an array")
+			return
+		}
+		for i, item := range list {
+			checkJSONType(fmt.Sprintf("%s[%d]", path, i), item, t.Elem(), errs)
+		}
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			mismatch("a string")
+		}
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			mismatch("a boolean")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := value.(json.Number)
+		if !ok {
+			mismatch("an integer")
+			return
+		}
+		if _, err := n.Int64(); err != nil {
+			mismatch("an integer")
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := value.(json.Number); !ok {
+			mismatch("a number")
+		}
+	}
+}
+
+// jsonFields maps the JSON names of a struct's fields to the fields
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func jsonTypeName(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case json.Number:
+		return "the number " + v.String()
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,94 @@
+package particles
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDecodeConfig(t *testing.T) {
+	for _, name := range PresetNames() {
+		data, err := json.Marshal(GetPreset(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := DecodeConfig(data); err != nil {
+			t.Errorf("preset %q: %v", name, err)
+		}
+	}
+}
+
+func TestDecodeConfigErrors(t *testing.T) {
+	tests := []struct {
+		desc  string
+		data  string
+		paths []string
+		msg   string
+	}{
+		{"syntax error", "{\n  \"particles\": {,}\n}", []string{""}, "invalid JSON at line 2, column 18"},
+		{"truncated", `{"particles": {`, []string{""}, "invalid JSON"},
+		{"trailing data", `{} {}`, []string{""}, "unexpected data after the configuration"},
+		{"not an object", `[1, 2]`, []string{""}, "expected an object, got an array"},
+		{"unknown field", `{"particles": {"numbr": {}}}`, []string{"particles.numbr"}, "unknown field"},
+		{"wrong type", `{"particles": {"number": {"value": "many"}}}`, []string{"particles.number.value"}, "expected an integer, got a string"},
+		{"fraction for integer", `{"particles": {"number": {"value": 1.5}}}`, []string{"particles.number.value"}, "expected an integer"},
+		{"array element", `{"gravity": {"planets": {"colors": ["#fff", 3]}}}`, []string{"gravity.planets.colors[1]"}, "expected a string, got the number 3"},
+		{"several problems", `{"particles": {"size": {"value": true}, "shape": {"typ": "x"}}}`,
+			[]string{"particles.shape.typ", "particles.size.value"}, ""},
+		{"invalid value", `{"particles": {"opacity": {"value": 2}}}`, []string{"particles.opacity.value"}, "must be between 0 and 1, got 2"},
+		{"invalid color", `{"particles": {"line_linked": {"color": "blue"}}}`, []string{"particles.line_linked.color"}, `invalid color "blue"`},
+		{"unknown mode", `{"particles": {"move": {"out_mode": "wrap"}}}`, []string{"particles.move.out_mode"}, `unknown value "wrap", expected one of out, bounce`},
+		{"gravity", `{"gravity": {"damping": 2, "mutual": {"mode": "exact"}}}`, []string{"gravity.damping", "gravity.mutual.mode"}, ""},
+	}
+	for _, tt := range tests {
+		_, err := DecodeConfig([]byte(tt.data))
+		paths := errorPaths(t, err)
+		if strings.Join(paths, ",") != strings.Join(tt.paths, ",") {
+			t.Errorf("%s: got errors at %v, want %v (%v)", tt.desc, paths, tt.paths, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("%s: got %q, want %q", tt.desc, err, tt.msg)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		desc   string
+		modify func(c *Config)
+		paths  []string
+	}{
+		{"default", func(c *Config) {}, nil},
+		{"negative number", func(c *Config) { c.Particles.Number.Value = -1 }, []string{"particles.number.value"}},
+		{"empty density area", func(c *Config) { c.Particles.Number.Density.ValueArea = 0 }, []string{"particles.number.density.value_area"}},
+		{"density disabled", func(c *Config) {
+			c.Particles.Number.Density = NumberDensity{Enable: false, ValueArea: 0}
+		}, nil},
+		{"color list", func(c *Config) { c.Particles.Color.Value = []interface{}{"#fff", "#123456"} }, nil},
+		{"bad color in list", func(c *Config) { c.Particles.Color.Value = []interface{}{"#fff", "red"} }, []string{"particles.color.value[1]"}},
+		{"unknown shape", func(c *Config) { c.Particles.Shape.Type = "hexagon" }, []string{"particles.shape.type"}},
+		{"unknown direction", func(c *Config) { c.Particles.Move.Direction = "up" }, []string{"particles.move.direction"}},
+		{"disabled hover mode ignored", func(c *Config) {
+			c.Interactivity.Events.OnHover = InteractivityEventMode{Enable: false, Mode: "spin"}
+		}, nil},
+		{"enabled hover mode", func(c *Config) {
+			c.Interactivity.Events.OnHover = InteractivityEventMode{Enable: true, Mode: "spin"}
+		}, []string{"interactivity.events.onhover.mode"}},
+		{"negative push", func(c *Config) { c.Interactivity.Modes.Push.ParticlesNb = -1 }, []string{"interactivity.modes.push.particles_nb"}},
+	}
+	for _, tt := range tests {
+		config := DefaultConfig()
+		tt.modify(config)
+		paths := errorPaths(t, config.Validate())
+		if strings.Join(paths, ",") != strings.Join(tt.paths, ",") {
+			t.Errorf("%s: got errors at %v, want %v", tt.desc, paths, tt.paths)
+		}
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	errs := ValidationErrors{{Path: "a.b", Message: "bad"}, {Message: "worse"}}
+	if got := errs.Error(); got != "a.b: bad; worse" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,60 @@
+package particles
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// RequestDetails carries what a handler learned about a request back to
+// the access log: the request ID and client IP assigned by the server and
+// the config ID and preset the handler served
+type RequestDetails struct {
+	RequestID string
+	ClientIP  string
+	ConfigID  string
+	Preset    string
+}
+
+type requestDetailsKey struct{}
+
+// ContextWithRequestDetails returns a context carrying details
+func ContextWithRequestDetails(ctx context.Context, details *RequestDetails) context.Context {
+	return context.WithValue(ctx, requestDetailsKey{}, details)
+}
+
+// RequestDetailsFrom returns the details carried by ctx. Without any it
+// returns a throwaway value, so handlers can always record into it.
+func RequestDetailsFrom(ctx context.Context) *RequestDetails {
+	if details, ok := ctx.Value(requestDetailsKey{}).(*RequestDetails); ok {
+		return details
+	}
+	return &RequestDetails{}
+}
+
+// ErrorBody is the JSON body of every error response
+type ErrorBody struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes a failed request
+type ErrorDetail struct {
+	Status    int    `json:"status"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// WriteError writes a JSON error response, including the request ID when
+// the request carries one
+func WriteError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	body := ErrorBody{Error: ErrorDetail{
+		Status:    status,
+		Message:   message,
+		RequestID: RequestDetailsFrom(r.Context()).RequestID,
+	}}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,37 @@
+package particles
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteError(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	rec := httptest.NewRecorder()
+	WriteError(rec, r, 400, "Bad request")
+
+	var body ErrorBody
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != 400 || body.Error != (ErrorDetail{Status: 400, Message: "Bad request"}) {
+		t.Errorf("got %d %+v", rec.Code, body)
+	}
+	if rec.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Error("missing nosniff")
+	}
+}
+
+func TestRequestDetails(t *testing.T) {
+	// Handlers record into the details without checking for them
+	RequestDetailsFrom(context.Background()).ConfigID = "lost"
+
+	details := &RequestDetails{RequestID: "abc"}
+	ctx := ContextWithRequestDetails(context.Background(), details)
+	RequestDetailsFrom(ctx).Preset = "snow"
+	if details.Preset != "snow" {
+		t.Error("details not shared through the context")
+	}
+}
@@ -0,0 +1,130 @@
+package particles
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"path"
+	"strings"
+	"sync"
+)
+
+// fingerprintLength is the number of hex digits of the content hash put in
+// fingerprinted URLs
+const fingerprintLength = 16
+
+// AssetInfo describes a fingerprinted asset
+type AssetInfo struct {
+	// Path is the asset's URL without a fingerprint, e.g.
+	// /js/particles.min.js
+	Path string
+	// FingerprintedPath embeds a hash of the content, e.g.
+	// /js/particles.min.3f2a9c0d1e4b5a67.js, so it can be cached forever
+	FingerprintedPath string
+	// Integrity is the Subresource Integrity value of the content
+	Integrity string
+}
+
+// AssetManifest maps asset URLs to their fingerprinted URLs and integrity
+// hashes
+type AssetManifest struct {
+	mu            sync.RWMutex
+	byPath        map[string]AssetInfo
+	byFingerprint map[string]AssetInfo
+}
+
+// NewAssetManifest creates an empty manifest
+func NewAssetManifest() *AssetManifest {
+	return &AssetManifest{
+		byPath:        make(map[string]AssetInfo),
+		byFingerprint: make(map[string]AssetInfo),
+	}
+}
+
+// Add hashes the content of the asset at urlPath and records it
+func (m *AssetManifest) Add(urlPath string, data []byte) AssetInfo {
+	sum := sha256.Sum256(data)
+	integrity := sha512.Sum384(data)
+	info := AssetInfo{
+		Path:              urlPath,
+		FingerprintedPath: fingerprintPath(urlPath, hex.EncodeToString(sum[:])[:fingerprintLength]),
+		Integrity:         "sha384-" + base64.StdEncoding.EncodeToString(integrity[:]),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if old, ok := m.byPath[urlPath]; ok {
+		delete(m.byFingerprint, old.FingerprintedPath)
+	}
+	m.byPath[urlPath] = info
+	m.byFingerprint[info.FingerprintedPath] = info
+	return info
+}
+
+// AddExternal records a script served elsewhere, such as a CDN, whose URL
+// already names its version, with the integrity pinned for it
+func (m *AssetManifest) AddExternal(url, integrity string) AssetInfo {
+	info := AssetInfo{Path: url, FingerprintedPath: url, Integrity: integrity}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.byPath[url] = info
+	return info
+}
+
+// CheckIntegrity reports whether data matches a Subresource Integrity
+// value using SHA-256, SHA-384 or SHA-512
+func CheckIntegrity(integrity string, data []byte) error {
+	algorithm, want, ok := strings.Cut(integrity, "-")
+	if !ok {
+		return fmt.Errorf("invalid integrity %q", integrity)
+	}
+	var h hash.Hash
+	switch algorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha384":
+		h = sha512.New384()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return fmt.Errorf("unsupported integrity algorithm %q", algorithm)
+	}
+	h.Write(data)
+	if got := base64.StdEncoding.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("integrity is %s-%s, want %s", algorithm, got, integrity)
+	}
+	return nil
+}
+
+// Lookup returns the asset at urlPath. A nil manifest has no assets.
+func (m *AssetManifest) Lookup(urlPath string) (AssetInfo, bool) {
+	if m == nil {
+		return AssetInfo{}, false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	info, ok := m.byPath[urlPath]
+	return info, ok
+}
+
+// LookupFingerprinted returns the asset whose fingerprinted URL is urlPath
+func (m *AssetManifest) LookupFingerprinted(urlPath string) (AssetInfo, bool) {
+	if m == nil {
+		return AssetInfo{}, false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	info, ok := m.byFingerprint[urlPath]
+	return info, ok
+}
+
+// fingerprintPath inserts hash before the extension of urlPath's file name:
+// /js/gravity.js becomes /js/gravity.<hash>.js
+func fingerprintPath(urlPath, hash string) string {
+	dir, file := path.Split(urlPath)
+	ext := path.Ext(file)
+	return dir + strings.TrimSuffix(file, ext) + "." + hash + ext
+}
@@ -0,0 +1,85 @@
+package particles
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestAssetManifest(t *testing.T) {
+	m := NewAssetManifest()
+	data := []byte("console.log('gravity')")
+	info := m.Add("/js/gravity.js", data)
+
+	if !strings.HasPrefix(info.FingerprintedPath, "/js/gravity.") || !strings.HasSuffix(info.FingerprintedPath, ".js") {
+		t.Errorf("fingerprinted path %q", info.FingerprintedPath)
+	}
+	if len(info.FingerprintedPath) != len("/js/gravity..js")+fingerprintLength {
+		t.Errorf("fingerprint length of %q", info.FingerprintedPath)
+	}
+	if err := CheckIntegrity(info.Integrity, data); err != nil {
+		t.Errorf("integrity doesn't match its content: %v", err)
+	}
+	if got, ok := m.Lookup("/js/gravity.js"); !ok || got != info {
+		t.Errorf("Lookup: %+v, %v", got, ok)
+	}
+	if got, ok := m.LookupFingerprinted(info.FingerprintedPath); !ok || got != info {
+		t.Errorf("LookupFingerprinted: %+v, %v", got, ok)
+	}
+
+	// Replacing an asset retires its old fingerprint
+	changed := m.Add("/js/gravity.js", []byte("console.log('changed')"))
+	if changed.FingerprintedPath == info.FingerprintedPath {
+		t.Error("fingerprint didn't change with the content")
+	}
+	if _, ok := m.LookupFingerprinted(info.FingerprintedPath); ok {
+		t.Error("old fingerprint still served")
+	}
+
+	var none *AssetManifest
+	if _, ok := none.Lookup("/js/gravity.js"); ok {
+		t.Error("nil manifest has assets")
+	}
+}
+
+func TestAssetManifestExternal(t *testing.T) {
+	m := NewAssetManifest()
+	url := "https://cdn.example.com/lib/1.0.0/lib.min.js"
+	m.AddExternal(url, "sha512-abc")
+
+	info, ok := m.Lookup(url)
+	if !ok || info.FingerprintedPath != url || info.Integrity != "sha512-abc" {
+		t.Errorf("Lookup: %+v, %v", info, ok)
+	}
+	if _, ok := m.LookupFingerprinted(url); ok {
+		t.Error("external script served locally")
+	}
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	data := []byte("particles")
+	sum256 := sha256.Sum256(data)
+	sum384 := sha512.Sum384(data)
+	sum512 := sha512.Sum512(data)
+	b64 := base64.StdEncoding.EncodeToString
+
+	tests := []struct {
+		integrity string
+		ok        bool
+	}{
+		{"sha256-" + b64(sum256[:]), true},
+		{"sha384-" + b64(sum384[:]), true},
+		{"sha512-" + b64(sum512[:]), true},
+		{"sha512-" + b64(sum384[:]), false},
+		{"sha1-" + b64(sum256[:]), false},
+		{b64(sum256[:]), false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if err := CheckIntegrity(tt.integrity, data); (err == nil) != tt.ok {
+			t.Errorf("%q: got %v, want ok %v", tt.integrity, err, tt.ok)
+		}
+	}
+}
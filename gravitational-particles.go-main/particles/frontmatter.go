@@ -0,0 +1,285 @@
+package particles
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PageParticles represents the `particles` block of a page's front matter.
+// The block is either a preset name or a mapping of the same keys the
+// shortcode accepts:
+//
+//	particles:
+//	  preset: snow
+//	  color: "#ff0000"
+//	  number: 200
+type PageParticles struct {
+	ID        string  `json:"id,omitempty"`
+	Preset    string  `json:"preset,omitempty"`
+	Color     string  `json:"color,omitempty"`
+	Shape     string  `json:"shape,omitempty"`
+	Number    int     `json:"number,omitempty"`
+	Size      float64 `json:"size,omitempty"`
+	Speed     float64 `json:"speed,omitempty"`
+	Direction string  `json:"direction,omitempty"`
+}
+
+// Params converts the block to shortcode parameters
+func (p *PageParticles) Params() map[string]string {
+	params := make(map[string]string)
+	set := func(key, value string) {
+		if value != "" {
+			params[key] = value
+		}
+	}
+	set("id", p.ID)
+	set("preset", p.Preset)
+	set("color", p.Color)
+	set("shape", p.Shape)
+	set("direction", p.Direction)
+	if p.Number != 0 {
+		params["number"] = strconv.Itoa(p.Number)
+	}
+	if p.Size != 0 {
+		params["size"] = strconv.FormatFloat(p.Size, 'f', -1, 64)
+	}
+	if p.Speed != 0 {
+		params["speed"] = strconv.FormatFloat(p.Speed, 'f', -1, 64)
+	}
+	return params
+}
+
+// Config builds the page's configuration on top of the site defaults and
+// validates it
+func (p *PageParticles) Config(site SiteParams) (*Config, error) {
+	params := site.MergeParams(p.Params())
+
+	if preset := params["preset"]; !IsPreset(preset) {
+		return nil, ValidationErrors{{Path: "particles.preset", Message: fmt.Sprintf("unknown preset %q", preset)}}
+	}
+
+	config := ConfigFromParams(params)
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// ParseFrontMatter extracts the particles block from a content file. YAML
+// (---), TOML (+++) and JSON ({) front matter are supported. It returns nil
+// when the page has no particles block. Front matter that doesn't parse
+// is only an error on pages that look like they have a particles block,
+// so that pages using YAML or TOML beyond what is supported still build.
+func ParseFrontMatter(content []byte) (*PageParticles, error) {
+	matter, err := frontMatter(content)
+	if err != nil && !particlesKey.Match(content) {
+		return nil, nil
+	}
+	if err != nil || matter == nil {
+		return nil, err
+	}
+
+	block, ok := matter["particles"]
+	if !ok || block == nil {
+		return nil, nil
+	}
+	return decodePageParticles(block)
+}
+
+// particlesKey matches a top-level particles key in YAML, TOML or JSON
+// front matter
+var particlesKey = regexp.MustCompile(`(?m)^(?:particles\s*[:=.]|\[\s*particles\s*[.\]]|\s*"particles"\s*:)|\{\s*"particles"\s*:`)
+
+// frontMatter decodes a content file's front matter into a generic map
+func frontMatter(content []byte) (map[string]interface{}, error) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+
+	switch {
+	case bytes.HasPrefix(content, []byte("---")):
+		body, err := delimited(content, "---")
+		if err != nil {
+			return nil, err
+		}
+		return parseYAML(body)
+	case bytes.HasPrefix(content, []byte("+++")):
+		body, err := delimited(content, "+++")
+		if err != nil {
+			return nil, err
+		}
+		return parseTOML(body)
+	case bytes.HasPrefix(content, []byte("{")):
+		var matter map[string]interface{}
+		dec := json.NewDecoder(bytes.NewReader(content))
+		dec.UseNumber()
+		if err := dec.Decode(&matter); err != nil {
+			return nil, fmt.Errorf("json front matter: %v", err)
+		}
+		return matter, nil
+	}
+	return nil, nil
+}
+
+// delimited returns the text between the opening and closing delimiter lines
+func delimited(content []byte, delim string) ([]byte, error) {
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	if len(lines) == 0 || strings.TrimSpace(string(lines[0])) != delim {
+		return nil, fmt.Errorf("malformed front matter delimiter")
+	}
+
+	var body []byte
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(string(line)) == delim {
+			return body, nil
+		}
+		body = append(body, line...)
+	}
+	return nil, fmt.Errorf("front matter is not closed by %q", delim)
+}
+
+func decodePageParticles(block interface{}) (*PageParticles, error) {
+	if preset, ok := block.(string); ok {
+		return &PageParticles{Preset: preset}, nil
+	}
+
+	fields, ok := block.(map[string]interface{})
+	if !ok {
+		return nil, ValidationErrors{{Path: "particles", Message: "expected a preset name or a mapping"}}
+	}
+
+	page := &PageParticles{}
+	var errs ValidationErrors
+	for key, value := range fields {
+		path := "particles." + key
+		var err error
+		switch strings.ToLower(key) {
+		case "id":
+			page.ID, err = frontMatterString(value)
+		case "preset":
+			page.Preset, err = frontMatterString(value)
+		case "color":
+			page.Color, err = frontMatterColor(value)
+		case "shape":
+			page.Shape, err = frontMatterString(value)
+		case "direction":
+			page.Direction, err = frontMatterString(value)
+		case "number":
+			var n float64
+			n, err = frontMatterNumber(value)
+			page.Number = int(n)
+			if err == nil && float64(page.Number) != n {
+				err = fmt.Errorf("expected a whole number, got %v", n)
+			}
+		case "size":
+			page.Size, err = frontMatterNumber(value)
+		case "speed":
+			page.Speed, err = frontMatterNumber(value)
+		default:
+			err = fmt.Errorf("unknown key")
+		}
+		if err != nil {
+			errs = append(errs, ValidationError{Path: path, Message: err.Error()})
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return page, nil
+}
+
+func frontMatterString(value interface{}) (string, error) {
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("expected a string, got %v", value)
+	}
+	return s, nil
+}
+
+// frontMatterColor accepts a single color or a palette list
+func frontMatterColor(value interface{}) (string, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return frontMatterString(value)
+	}
+
+	colors := make([]string, len(list))
+	for i, item := range list {
+		s, err := frontMatterString(item)
+		if err != nil {
+			return "", err
+		}
+		colors[i] = s
+	}
+	return strings.Join(colors, ","), nil
+}
+
+func frontMatterNumber(value interface{}) (float64, error) {
+	switch n := value.(type) {
+	case int64:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	case json.Number:
+		return n.Float64()
+	case string:
+		if f, err := strconv.ParseFloat(n, 64); err == nil {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("expected a number, got %v", value)
+}
+
+// PageScan is the result of checking one content file's particles block
+type PageScan struct {
+	Path   string         `json:"path"`
+	Page   *PageParticles `json:"particles,omitempty"`
+	Config *Config        `json:"-"`
+	Err    error          `json:"-"`
+}
+
+// ScanContent walks a Hugo content directory and checks the particles
+// block of every Markdown and HTML file that has one
+func ScanContent(dir string, site SiteParams) ([]PageScan, error) {
+	var results []PageScan
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".md", ".markdown", ".html":
+		default:
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		page, err := ParseFrontMatter(content)
+		if err != nil {
+			results = append(results, PageScan{Path: path, Err: err})
+			return nil
+		}
+		if page == nil {
+			return nil
+		}
+
+		config, err := page.Config(site)
+		results = append(results, PageScan{Path: path, Page: page, Config: config, Err: err})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error scanning content: %v", err)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,31 @@
+package particles
+
+import "github.com/yourusername/particles-go/particles/physics"
+
+// outModeBoundaries maps particles.js out modes to the boundary modes
+// behaving alike
+var outModeBoundaries = map[string]string{
+	"out":    physics.BoundaryWrap,
+	"bounce": physics.BoundaryBounce,
+}
+
+// GravitySettings returns the settings of the configuration's gravity
+// simulation, the gravity.js defaults when it has none. Settings left to
+// particles.js follow it: the edges behave as move.out_mode says unless
+// gravity.boundary.mode is set, and move.bounce makes planets collide
+// as particles do.
+func (c *Config) GravitySettings() physics.GravityConfig {
+	g := physics.DefaultGravityConfig()
+	if c.Gravity == nil {
+		return g
+	}
+
+	g = *c.Gravity
+	if g.Boundary.Mode == "" {
+		g.Boundary.Mode = outModeBoundaries[c.Particles.Move.OutMode]
+	}
+	if c.Particles.Move.Bounce {
+		g.Collision.Bodies = true
+	}
+	return g
+}
@@ -0,0 +1,37 @@
+package particles
+
+import (
+	"testing"
+
+	"github.com/yourusername/particles-go/particles/physics"
+)
+
+func TestGravitySettingsBoundary(t *testing.T) {
+	tests := []struct {
+		outMode  string
+		boundary string
+		want     string
+	}{
+		{"out", "", physics.BoundaryWrap},
+		{"bounce", "", physics.BoundaryBounce},
+		{"destroy", "", ""},
+		{"out", physics.BoundarySoft, physics.BoundarySoft},
+	}
+	for _, tt := range tests {
+		config := DefaultConfig()
+		config.Particles.Move.OutMode = tt.outMode
+		gravity := physics.DefaultGravityConfig()
+		gravity.Boundary.Mode = tt.boundary
+		config.Gravity = &gravity
+		if got := config.GravitySettings().Boundary.Mode; got != tt.want {
+			t.Errorf("out_mode %q, boundary %q: got %q, want %q", tt.outMode, tt.boundary, got, tt.want)
+		}
+	}
+
+	// Without a gravity section the simulation is gravity.js's
+	config := DefaultConfig()
+	config.Particles.Move.OutMode = "bounce"
+	if got := config.GravitySettings().Boundary.Mode; got != "" {
+		t.Errorf("no gravity section: got boundary %q", got)
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"log/slog"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 )
@@ -15,16 +17,39 @@ type HugoShortcodeData struct {
 	ElementID      string
 	ConfigEndpoint string
 	JsPath         string
+	// Integrity is the Subresource Integrity value of the script at
+	// JsPath, when known
+	Integrity     string
+	ReducedMotion string
 }
 
 // HugoHandler processes particles requests for Hugo
 type HugoHandler struct {
 	ConfigEndpoint  string
 	StaticJsPath    string
-	ParticlesCache  map[string]*Config
+	Store           ConfigStore
 	DefaultConfigID string
+	Site            SiteParams
+	// Format is the format configurations are served in unless a request
+	// asks for another with ?format=: FormatParticlesJS or FormatTSParticles
+	Format string
+	// Metrics, when set, counts preset uses and random configs
+	Metrics *Metrics
+	// RandomLimiter, when set, caps the random configs each client may
+	// generate by requesting unknown config IDs, and the gravity warm-ups
+	// it may cause that aren't cached yet; it is keyed by client IP
+	RandomLimiter Limiter
+	// CORS, when set, lets pages on other origins load configurations
+	CORS *CORSPolicy
+	// Assets, when set, fingerprints the script URLs of Shortcode so that
+	// upgrades never mix cached and new versions
+	Assets *AssetManifest
 }
 
+// shortcodeParams are the query parameters the shortcode's config-url mode
+// sends to describe a configuration instead of naming a stored one
+var shortcodeParams = []string{"preset", "color", "number", "shape", "size", "speed", "direction"}
+
 // NewHugoHandler creates a new Hugo handler
 func NewHugoHandler(configEndpoint, staticJsPath string) *HugoHandler {
 	// Generate a default config ID
@@ -33,39 +58,117 @@ func NewHugoHandler(configEndpoint, staticJsPath string) *HugoHandler {
 	handler := &HugoHandler{
 		ConfigEndpoint:  configEndpoint,
 		StaticJsPath:    staticJsPath,
-		ParticlesCache:  make(map[string]*Config),
+		Store:           NewMemoryStore(),
 		DefaultConfigID: defaultID,
+		Site:            DefaultSiteParams(),
+		Format:          FormatParticlesJS,
 	}
 
-	// Add default config to cache
-	handler.ParticlesCache[defaultID] = DefaultConfig()
+	// Add default config to the store
+	handler.Store.Put(defaultID, DefaultConfig())
 
 	return handler
 }
 
 // ServeHTTP handles HTTP requests for particle configurations
 func (h *HugoHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Answer CORS preflight requests before doing any work
+	if h.CORS.Handle(w, r) {
+		return
+	}
+
 	// Set JSON content type
 	w.Header().Set("Content-Type", "application/json")
 
 	// Parse request
-	configID := r.URL.Query().Get("config")
-	if configID == "" {
-		configID = h.DefaultConfigID
+	query := r.URL.Query()
+	configID := query.Get("config")
+	format := query.Get("format")
+	if format == "" {
+		format = h.Format
+	}
+	if format != "" && format != FormatParticlesJS && format != FormatTSParticles {
+		WriteError(w, r, http.StatusBadRequest, "Unknown format")
+		return
 	}
 
-	// Check if we already have this config
-	config, exists := h.ParticlesCache[configID]
-	if !exists {
-		// Generate a random config
-		config = RandomParticlesConfig()
-		h.ParticlesCache[configID] = config
+	details := RequestDetailsFrom(r.Context())
+	if details.ClientIP == "" {
+		details.ClientIP = ClientIP(r, false)
+	}
+
+	var config *Config
+	if params := queryParams(query); configID == "" && len(params) > 0 {
+		// Build the config the shortcode parameters describe
+		params = h.Site.MergeParams(params)
+		details.Preset = params["preset"]
+		config = h.configFromParams(params)
+	} else {
+		if configID == "" {
+			configID = h.DefaultConfigID
+		}
+		if !ValidConfigID(configID) {
+			WriteError(w, r, http.StatusBadRequest, "Invalid config ID")
+			return
+		}
+		details.ConfigID = configID
+
+		// Check if we already have this config
+		var exists bool
+		var err error
+		config, exists, err = h.Store.Get(configID)
+		if err != nil {
+			slog.Error("error loading config", "request_id", details.RequestID, "config_id", configID, "error", err)
+			WriteError(w, r, http.StatusInternalServerError, "Error loading config")
+			return
+		}
+		if !exists {
+			if configID == h.DefaultConfigID {
+				// The default config may have been evicted from a bounded store
+				config = DefaultConfig()
+			} else {
+				if h.RandomLimiter != nil && !h.RandomLimiter.Allow(details.ClientIP) {
+					WriteError(w, r, http.StatusTooManyRequests, "Too many random configurations requested")
+					return
+				}
+				// Generate a random config
+				config = RandomParticlesConfig()
+				h.Metrics.RandomGenerated()
+			}
+			if err := h.Store.Put(configID, config); err != nil {
+				slog.Error("error storing config", "request_id", details.RequestID, "config_id", configID, "error", err)
+				WriteError(w, r, http.StatusInternalServerError, "Error storing config")
+				return
+			}
+		}
+	}
+
+	// Start gravity simulations from their settled state. Stored configs
+	// are warmed up when stored, so this only runs for parameters and
+	// configs stored before; clients may not force unlimited warm-ups.
+	if config.needsWarmUp() {
+		warmed := config.warmUp(func() bool {
+			return h.RandomLimiter == nil || h.RandomLimiter.Allow(details.ClientIP)
+		})
+		if configID != "" && warmed != config {
+			if err := h.Store.Put(configID, warmed); err != nil {
+				slog.Error("error storing config", "request_id", details.RequestID, "config_id", configID, "error", err)
+			}
+		}
+		config = warmed
+	}
+
+	// Convert to the requested format
+	var body interface{} = config
+	if format == FormatTSParticles {
+		body = config.ToTSParticles()
 	}
 
 	// Marshal config to JSON
-	jsonData, err := json.Marshal(config)
+	jsonData, err := json.Marshal(body)
 	if err != nil {
-		http.Error(w, "Error generating JSON", http.StatusInternalServerError)
+		slog.Error("error marshaling config", "request_id", details.RequestID, "error", err)
+		WriteError(w, r, http.StatusInternalServerError, "Error generating JSON")
 		return
 	}
 
@@ -75,6 +178,9 @@ func (h *HugoHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // GenerateHugoShortcodeData creates data for the Hugo shortcode
 func (h *HugoHandler) GenerateHugoShortcodeData(params map[string]string) HugoShortcodeData {
+	// Fill in site-wide defaults for parameters the shortcode didn't set
+	params = h.Site.MergeParams(params)
+
 	// Get or create a config ID
 	configID := params["config"]
 	if configID == "" {
@@ -87,47 +193,48 @@ func (h *HugoHandler) GenerateHugoShortcodeData(params map[string]string) HugoSh
 		elementID = fmt.Sprintf("particles-%s", configID)
 	}
 
-	// Create a configuration for this instance
-	config := DefaultConfig()
+	// Create a configuration for this instance, warmed up once here
+	// rather than on every request for it
+	config := h.configFromParams(params).WarmUp()
 
-	// Process parameters to update config
-	for k, v := range params {
-		switch k {
-		case "preset":
-			if preset, ok := v, true; ok {
-				config = GetPreset(preset)
-			}
-		case "color":
-			config.Particles.Color.Value = v
-		case "shape":
-			config.Particles.Shape.Type = v
-		case "number":
-			if val, err := strconv.Atoi(v); err == nil {
-				config.Particles.Number.Value = val
-			}
-		case "size":
-			if val, err := strconv.ParseFloat(v, 64); err == nil {
-				config.Particles.Size.Value = val
-			}
-		case "speed":
-			if val, err := strconv.ParseFloat(v, 64); err == nil {
-				config.Particles.Move.Speed = val
-			}
-		case "direction":
-			config.Particles.Move.Direction = v
-		}
+	// Store config for the endpoint to serve
+	if err := h.Store.Put(configID, config); err != nil {
+		slog.Error("error storing config", "config_id", configID, "error", err)
 	}
 
-	// Store config in cache
-	h.ParticlesCache[configID] = config
-
 	// Build config endpoint URL
-	configURL := fmt.Sprintf("%s?config=%s", h.ConfigEndpoint, configID)
+	endpoint := params["config-url"]
+	if endpoint == "" {
+		endpoint = h.ConfigEndpoint
+	}
+	configURL := fmt.Sprintf("%s?config=%s", endpoint, configID)
+
+	jsPath := params["js-path"]
+	if jsPath == "" {
+		jsPath = h.StaticJsPath
+	}
+
+	// Point at the fingerprinted copy of the script when the server knows it
+	integrity := ""
+	if info, ok := h.Assets.Lookup(jsPath); ok {
+		jsPath = info.FingerprintedPath
+		integrity = info.Integrity
+	}
+
+	// Unknown policies get the default rather than reaching the page
+	reducedMotion := params["reduced-motion"]
+	switch reducedMotion {
+	case ReducedMotionRespect, ReducedMotionDisable, ReducedMotionIgnore:
+	default:
+		reducedMotion = ReducedMotionRespect
+	}
 
 	return HugoShortcodeData{
 		ElementID:      elementID,
 		ConfigEndpoint: configURL,
-		JsPath:         h.StaticJsPath,
+		JsPath:         jsPath,
+		Integrity:      integrity,
+		ReducedMotion:  reducedMotion,
 	}
 }
 
@@ -135,18 +242,101 @@ func (h *HugoHandler) GenerateHugoShortcodeData(params map[string]string) HugoSh
 func (h *HugoHandler) Shortcode(params map[string]string) template.HTML {
 	data := h.GenerateHugoShortcodeData(params)
 
+	// Let the browser check the script against its hash
+	integrity := ""
+	if data.Integrity != "" {
+		integrity = fmt.Sprintf(` integrity="%s" crossorigin="anonymous"`, template.HTMLEscapeString(data.Integrity))
+	}
+
 	// Create HTML output
 	html := fmt.Sprintf(`
 <div id="%s" style="width: 100%%; height: 100%%; position: absolute; top: 0; left: 0; z-index: -1;"></div>
-<script src="%s"></script>
+<script src="%s"%s></script>
 <script>
 document.addEventListener('DOMContentLoaded', function() {
-  particlesJS.load('%s', '%s', function() {
+  var reduce = window.matchMedia && window.matchMedia('(prefers-reduced-motion: reduce)').matches;
+  var policy = '%s';
+  if (reduce && policy === 'disable') {
+    return;
+  }
+  var id = '%s';
+  particlesJS.load(id, '%s', function() {
+    if (reduce && policy === 'respect') {
+      // Other instances on the page may have loaded since this one started
+      var container = document.getElementById(id);
+      for (var i = 0; i < window.pJSDom.length; i++) {
+        var pJS = window.pJSDom[i].pJS;
+        if (container && container.contains(pJS.canvas.el)) {
+          pJS.particles.move.enable = false;
+        }
+      }
+    }
     console.log('particles.js loaded');
   });
 });
 </script>
-`, data.ElementID, data.JsPath, data.ElementID, data.ConfigEndpoint)
+`, template.HTMLEscapeString(data.ElementID), template.HTMLEscapeString(data.JsPath), integrity,
+		template.JSEscapeString(data.ReducedMotion), template.JSEscapeString(data.ElementID), template.JSEscapeString(data.ConfigEndpoint))
 
 	return template.HTML(html)
 }
+
+// configFromParams builds a configuration from shortcode parameters and
+// counts the preset it starts from
+func (h *HugoHandler) configFromParams(params map[string]string) *Config {
+	if preset := params["preset"]; preset != "" {
+		// Unknown names fall back to the default preset; counting them
+		// under their own name would let clients create label values
+		if !IsPreset(preset) {
+			preset = PresetDefault
+		}
+		h.Metrics.PresetUsed(preset)
+	}
+	return ConfigFromParams(params)
+}
+
+// queryParams extracts the shortcode parameters from a request query
+func queryParams(query url.Values) map[string]string {
+	params := make(map[string]string)
+	for _, key := range shortcodeParams {
+		if v := query.Get(key); v != "" {
+			params[key] = v
+		}
+	}
+	return params
+}
+
+// ConfigFromParams builds a configuration from shortcode parameters,
+// starting from the preset so that the remaining parameters override it
+func ConfigFromParams(params map[string]string) *Config {
+	config := DefaultConfig()
+	if preset, ok := params["preset"]; ok {
+		config = GetPreset(preset)
+	}
+
+	// Process parameters to update config
+	for k, v := range params {
+		switch k {
+		case "color":
+			config.Particles.Color.Value = ColorValue(v)
+		case "shape":
+			config.Particles.Shape.Type = v
+		case "number":
+			if val, err := strconv.Atoi(v); err == nil {
+				config.Particles.Number.Value = val
+			}
+		case "size":
+			if val, err := strconv.ParseFloat(v, 64); err == nil {
+				config.Particles.Size.Value = val
+			}
+		case "speed":
+			if val, err := strconv.ParseFloat(v, 64); err == nil {
+				config.Particles.Move.Speed = val
+			}
+		case "direction":
+			config.Particles.Move.Direction = v
+		}
+	}
+
+	return config
+}
@@ -0,0 +1,60 @@
+package particles
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShortcodeReducedMotion(t *testing.T) {
+	h := NewHugoHandler("/api/particles-config", "/js/particles.min.js")
+
+	tests := []struct {
+		param string
+		want  string
+	}{
+		{"", ReducedMotionRespect},
+		{ReducedMotionRespect, ReducedMotionRespect},
+		{ReducedMotionDisable, ReducedMotionDisable},
+		{ReducedMotionIgnore, ReducedMotionIgnore},
+		{"'; alert(1); '", ReducedMotionRespect},
+		{"DISABLE", ReducedMotionRespect},
+	}
+	for _, tt := range tests {
+		params := map[string]string{"config": "test"}
+		if tt.param != "" {
+			params["reduced-motion"] = tt.param
+		}
+		if got := h.GenerateHugoShortcodeData(params).ReducedMotion; got != tt.want {
+			t.Errorf("%q: got policy %q, want %q", tt.param, got, tt.want)
+		}
+		html := string(h.Shortcode(params))
+		if !strings.Contains(html, "var policy = '"+tt.want+"';") {
+			t.Errorf("%q: policy not set in\n%s", tt.param, html)
+		}
+		if strings.Contains(html, "alert(1)") {
+			t.Errorf("%q: parameter reached the page", tt.param)
+		}
+	}
+}
+
+func TestShortcodeEscapesParams(t *testing.T) {
+	h := NewHugoHandler("/api/particles-config", "/js/particles.min.js")
+	html := string(h.Shortcode(map[string]string{
+		"config": "test",
+		"id":     `x"></div><script>alert(1)</script>`,
+	}))
+	if strings.Contains(html, "<script>alert(1)") {
+		t.Errorf("element ID not escaped:\n%s", html)
+	}
+}
+
+func TestShortcodeFindsItsInstance(t *testing.T) {
+	h := NewHugoHandler("/api/particles-config", "/js/particles.min.js")
+	html := string(h.Shortcode(map[string]string{"config": "test", "id": "hero"}))
+	if strings.Contains(html, "pJSDom.length - 1") {
+		t.Error("shortcode assumes its instance loaded last")
+	}
+	if !strings.Contains(html, "var id = 'hero';") || !strings.Contains(html, "container.contains(pJS.canvas.el)") {
+		t.Errorf("shortcode doesn't look up its own instance:\n%s", html)
+	}
+}
@@ -0,0 +1,65 @@
+package particles
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// HugoDataVersion is the schema version of the generated Hugo data file
+const HugoDataVersion = 1
+
+// HugoData is the document the Hugo module reads from data/particles.json.
+// The shortcode resolves presets from it, so the Go presets stay the single
+// source of truth for both the config server and static Hugo builds.
+type HugoData struct {
+	Version       int                `json:"version"`
+	DefaultPreset string             `json:"default_preset"`
+	Presets       map[string]*Config `json:"presets"`
+}
+
+// BuildHugoData collects every preset into a Hugo data document, using the
+// site's default preset when it sets one
+func BuildHugoData(site SiteParams) *HugoData {
+	data := &HugoData{
+		Version:       HugoDataVersion,
+		DefaultPreset: PresetDefault,
+		Presets:       make(map[string]*Config),
+	}
+	if site.DefaultPreset != "" {
+		data.DefaultPreset = site.DefaultPreset
+	}
+
+	for _, name := range PresetNames() {
+		data.Presets[name] = GetPreset(name).WarmUp()
+	}
+
+	return data
+}
+
+// ToJSON converts the data document to indented JSON
+func (d *HugoData) ToJSON() ([]byte, error) {
+	bytes, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling Hugo data to JSON: %v", err)
+	}
+	return append(bytes, '\n'), nil
+}
+
+// WriteFile writes the data document to path, creating parent directories
+func (d *HugoData) WriteFile(path string) error {
+	bytes, err := d.ToJSON()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating data directory: %v", err)
+	}
+
+	if err := os.WriteFile(path, bytes, 0o644); err != nil {
+		return fmt.Errorf("error writing Hugo data file: %v", err)
+	}
+	return nil
+}
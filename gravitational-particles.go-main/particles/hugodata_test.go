@@ -0,0 +1,66 @@
+package particles
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildHugoData(t *testing.T) {
+	data := BuildHugoData(SiteParams{DefaultPreset: PresetSnow})
+	if data.Version != HugoDataVersion || data.DefaultPreset != PresetSnow {
+		t.Errorf("got version %d, default preset %q", data.Version, data.DefaultPreset)
+	}
+	for _, name := range PresetNames() {
+		config, ok := data.Presets[name]
+		if !ok {
+			t.Errorf("preset %q missing", name)
+			continue
+		}
+		if err := config.Validate(); err != nil {
+			t.Errorf("preset %q: %v", name, err)
+		}
+	}
+	if len(data.Presets) != len(PresetNames()) {
+		t.Errorf("got %d presets, want %d", len(data.Presets), len(PresetNames()))
+	}
+
+	if got := BuildHugoData(SiteParams{}).DefaultPreset; got != PresetDefault {
+		t.Errorf("default preset without a site setting is %q", got)
+	}
+}
+
+func TestHugoDataWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "particles.json")
+	want := BuildHugoData(DefaultSiteParams())
+	if err := want.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got HugoData
+	if err := json.Unmarshal(content, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != want.Version || len(got.Presets) != len(want.Presets) {
+		t.Errorf("read back version %d with %d presets", got.Version, len(got.Presets))
+	}
+}
+
+// The Hugo module ships the generated file; it must match the presets
+func TestHugoDataFileUpToDate(t *testing.T) {
+	content, err := os.ReadFile("../data/particles.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := BuildHugoData(DefaultSiteParams()).ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != string(want) {
+		t.Error("data/particles.json is out of date; run go generate")
+	}
+}
@@ -0,0 +1,176 @@
+package particles
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds in seconds of the request duration
+// histogram, matching the Prometheus client defaults
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics collects usage counters for the config server and renders them
+// in the Prometheus text exposition format. A nil *Metrics ignores all
+// observations, so instrumented code needn't check whether it is enabled.
+type Metrics struct {
+	mu            sync.Mutex
+	requests      map[requestKey]*histogram
+	presets       map[string]uint64
+	randomConfigs uint64
+
+	// Store is read at scrape time for cache statistics when it
+	// implements StatsReporter
+	Store ConfigStore
+}
+
+type requestKey struct {
+	route  string
+	status int
+}
+
+type histogram struct {
+	counts []uint64 // Per bucket, not cumulative; the last is +Inf
+	sum    float64
+	count  uint64
+}
+
+// NewMetrics creates an empty metrics collector
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests: make(map[requestKey]*histogram),
+		presets:  make(map[string]uint64),
+	}
+}
+
+// ObserveRequest records a served request by route and status code
+func (m *Metrics) ObserveRequest(route string, status int, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := requestKey{route, status}
+	h, ok := m.requests[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(latencyBuckets)+1)}
+		m.requests[key] = h
+	}
+
+	seconds := d.Seconds()
+	i := sort.SearchFloat64s(latencyBuckets, seconds)
+	h.counts[i]++
+	h.sum += seconds
+	h.count++
+}
+
+// PresetUsed records that a configuration was built from a preset
+func (m *Metrics) PresetUsed(name string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.presets[name]++
+}
+
+// RandomGenerated records that a random configuration was generated
+func (m *Metrics) RandomGenerated() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.randomConfigs++
+}
+
+// WritePrometheus writes the metrics in the Prometheus text format
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+
+	keys := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		return keys[i].status < keys[j].status
+	})
+
+	b.WriteString("# HELP particles_http_requests_total HTTP requests served, by route and status code.\n")
+	b.WriteString("# TYPE particles_http_requests_total counter\n")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "particles_http_requests_total{route=%s,status=\"%d\"} %d\n",
+			quoteLabel(key.route), key.status, m.requests[key].count)
+	}
+
+	b.WriteString("# HELP particles_http_request_duration_seconds Time taken to serve HTTP requests.\n")
+	b.WriteString("# TYPE particles_http_request_duration_seconds histogram\n")
+	for _, key := range keys {
+		h := m.requests[key]
+		labels := fmt.Sprintf("route=%s,status=\"%d\"", quoteLabel(key.route), key.status)
+		var cumulative uint64
+		for i, bound := range latencyBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(&b, "particles_http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(&b, "particles_http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(&b, "particles_http_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "particles_http_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	if reporter, ok := m.Store.(StatsReporter); ok {
+		stats := reporter.Stats()
+		b.WriteString("# HELP particles_store_hits_total Config store lookups that found a configuration.\n")
+		b.WriteString("# TYPE particles_store_hits_total counter\n")
+		fmt.Fprintf(&b, "particles_store_hits_total %d\n", stats.Hits)
+		b.WriteString("# HELP particles_store_misses_total Config store lookups that found nothing.\n")
+		b.WriteString("# TYPE particles_store_misses_total counter\n")
+		fmt.Fprintf(&b, "particles_store_misses_total %d\n", stats.Misses)
+		b.WriteString("# HELP particles_store_evictions_total Configurations evicted from a full config store.\n")
+		b.WriteString("# TYPE particles_store_evictions_total counter\n")
+		fmt.Fprintf(&b, "particles_store_evictions_total %d\n", stats.Evictions)
+	}
+
+	names := make([]string, 0, len(m.presets))
+	for name := range m.presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	b.WriteString("# HELP particles_preset_uses_total Configurations built from each preset.\n")
+	b.WriteString("# TYPE particles_preset_uses_total counter\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "particles_preset_uses_total{preset=%s} %d\n", quoteLabel(name), m.presets[name])
+	}
+
+	b.WriteString("# HELP particles_random_configs_total Random configurations generated.\n")
+	b.WriteString("# TYPE particles_random_configs_total counter\n")
+	fmt.Fprintf(&b, "particles_random_configs_total %d\n", m.randomConfigs)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ServeHTTP serves the metrics to a Prometheus scraper
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WritePrometheus(w)
+}
+
+// quoteLabel quotes a label value, escaping as the text format requires
+func quoteLabel(value string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(value) + `"`
+}
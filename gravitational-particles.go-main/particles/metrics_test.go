@@ -0,0 +1,64 @@
+package particles
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	store := NewMemoryStore()
+	store.Put("a", DefaultConfig())
+	store.Get("a")
+	store.Get("b")
+
+	m := NewMetrics()
+	m.Store = store
+	m.ObserveRequest("/api/particles-config", 200, 3*time.Millisecond)
+	m.ObserveRequest("/api/particles-config", 200, 300*time.Millisecond)
+	m.ObserveRequest("/api/particles-config", 404, time.Millisecond)
+	m.PresetUsed("snow")
+	m.PresetUsed("snow")
+	m.PresetUsed(`odd"name`)
+	m.RandomGenerated()
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("got content type %q", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`particles_http_requests_total{route="/api/particles-config",status="200"} 2`,
+		`particles_http_requests_total{route="/api/particles-config",status="404"} 1`,
+		`particles_http_request_duration_seconds_bucket{route="/api/particles-config",status="200",le="0.005"} 1`,
+		`particles_http_request_duration_seconds_bucket{route="/api/particles-config",status="200",le="0.25"} 1`,
+		`particles_http_request_duration_seconds_bucket{route="/api/particles-config",status="200",le="0.5"} 2`,
+		`particles_http_request_duration_seconds_bucket{route="/api/particles-config",status="200",le="+Inf"} 2`,
+		`particles_http_request_duration_seconds_sum{route="/api/particles-config",status="200"} 0.303`,
+		`particles_http_request_duration_seconds_count{route="/api/particles-config",status="200"} 2`,
+		"particles_store_hits_total 1\n",
+		"particles_store_misses_total 1\n",
+		`particles_preset_uses_total{preset="snow"} 2`,
+		`particles_preset_uses_total{preset="odd\"name"} 1`,
+		"particles_random_configs_total 1\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+}
+
+func TestMetricsNil(t *testing.T) {
+	var m *Metrics
+	m.ObserveRequest("/", 200, time.Second)
+	m.PresetUsed("snow")
+	m.RandomGenerated()
+}
+
+func TestQuoteLabel(t *testing.T) {
+	if got, want := quoteLabel("a\\b\"c\nd"), `"a\\b\"c\nd"`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"math/rand"
 	"time"
+
+	"github.com/yourusername/particles-go/particles/physics"
 )
 
 func init() {
@@ -188,11 +190,25 @@ type Interactivity struct {
 	Modes    InteractivityModes  `json:"modes"`
 }
 
+// ConfigDemo represents the page styling used by the particles.js demo
+type ConfigDemo struct {
+	HideCard           bool   `json:"hide_card"`
+	BackgroundColor    string `json:"background_color"`
+	BackgroundImage    string `json:"background_image"`
+	BackgroundPosition string `json:"background_position"`
+	BackgroundRepeat   string `json:"background_repeat"`
+	BackgroundSize     string `json:"background_size"`
+}
+
 // Config represents the overall particles.js configuration
 type Config struct {
 	Particles     ParticlesConfig `json:"particles"`
 	Interactivity Interactivity   `json:"interactivity"`
 	RetinaDetect  bool            `json:"retina_detect"`
+	ConfigDemo    *ConfigDemo     `json:"config_demo,omitempty"`
+	// Gravity, when set, describes the sun-and-planets simulation run by
+	// gravity.js and the physics package
+	Gravity *physics.GravityConfig `json:"gravity,omitempty"`
 }
 
 // DefaultConfig returns the default configuration
@@ -318,8 +334,40 @@ const (
 	PresetBubbles   = "bubbles"
 )
 
+// PresetNames returns the names of all predefined configurations, the
+// built-in ones first followed by registered presets
+func PresetNames() []string {
+	names := []string{
+		PresetDefault,
+		PresetSnow,
+		PresetNightSky,
+		PresetSpacyDots,
+		PresetBubbles,
+	}
+	for _, name := range customPresetNames() {
+		if !containsString(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// IsPreset reports whether name is a predefined configuration
+func IsPreset(name string) bool {
+	for _, preset := range PresetNames() {
+		if preset == name {
+			return true
+		}
+	}
+	return false
+}
+
 // GetPreset returns a predefined configuration
 func GetPreset(preset string) *Config {
+	if config, ok := customPreset(preset); ok {
+		return config
+	}
+
 	switch preset {
 	case PresetSnow:
 		return &Config{
@@ -620,7 +668,7 @@ func GenerateConfig(params map[string]interface{}) *Config {
 	}
 
 	if color, ok := params["color"].(string); ok {
-		config.Particles.Color.Value = color
+		config.Particles.Color.Value = ColorValue(color)
 	}
 
 	if shape, ok := params["shape"].(string); ok {
@@ -677,50 +725,72 @@ func (c *Config) ToJSON() (string, error) {
 
 // RandomParticlesConfig generates a random particles configuration
 func RandomParticlesConfig() *Config {
+	return randomConfig(globalRand{})
+}
+
+// NewRandomConfig generates a random particles configuration from rng, so
+// that a seeded generator always produces the same configuration
+func NewRandomConfig(rng *rand.Rand) *Config {
+	return randomConfig(rng)
+}
+
+// randSource is the part of *rand.Rand used to randomize configurations
+type randSource interface {
+	Intn(n int) int
+	Float64() float64
+}
+
+// globalRand is a randSource backed by the shared math/rand generator
+type globalRand struct{}
+
+func (globalRand) Intn(n int) int   { return rand.Intn(n) }
+func (globalRand) Float64() float64 { return rand.Float64() }
+
+func randomConfig(rng randSource) *Config {
 	config := DefaultConfig()
 
 	// Randomize particles number
-	config.Particles.Number.Value = rand.Intn(150) + 50
+	config.Particles.Number.Value = rng.Intn(150) + 50
 
 	// Randomize particles color
 	colors := []string{"#ffffff", "#e74c3c", "#3498db", "#2ecc71", "#f1c40f", "#9b59b6"}
-	config.Particles.Color.Value = colors[rand.Intn(len(colors))]
+	config.Particles.Color.Value = colors[rng.Intn(len(colors))]
 
 	// Randomize particles shape
 	shapes := []string{"circle", "edge", "triangle", "polygon", "star"}
-	config.Particles.Shape.Type = shapes[rand.Intn(len(shapes))]
+	config.Particles.Shape.Type = shapes[rng.Intn(len(shapes))]
 
 	// Randomize particles size
-	config.Particles.Size.Value = float64(rand.Intn(10) + 1)
-	config.Particles.Size.Random = rand.Intn(2) == 0
+	config.Particles.Size.Value = float64(rng.Intn(10) + 1)
+	config.Particles.Size.Random = rng.Intn(2) == 0
 
 	// Randomize particles opacity
-	config.Particles.Opacity.Value = 0.1 + rand.Float64()*0.9
-	config.Particles.Opacity.Random = rand.Intn(2) == 0
+	config.Particles.Opacity.Value = 0.1 + rng.Float64()*0.9
+	config.Particles.Opacity.Random = rng.Intn(2) == 0
 
 	// Randomize particles movement
-	config.Particles.Move.Speed = float64(rand.Intn(10) + 1)
+	config.Particles.Move.Speed = float64(rng.Intn(10) + 1)
 	directions := []string{"none", "top", "top-right", "right", "bottom-right", "bottom", "bottom-left", "left", "top-left"}
-	config.Particles.Move.Direction = directions[rand.Intn(len(directions))]
-	config.Particles.Move.Random = rand.Intn(2) == 0
-	config.Particles.Move.Straight = rand.Intn(2) == 0
+	config.Particles.Move.Direction = directions[rng.Intn(len(directions))]
+	config.Particles.Move.Random = rng.Intn(2) == 0
+	config.Particles.Move.Straight = rng.Intn(2) == 0
 
 	// Randomize line linking
-	config.Particles.LineLinked.Enable = rand.Intn(2) == 0
+	config.Particles.LineLinked.Enable = rng.Intn(2) == 0
 	if config.Particles.LineLinked.Enable {
-		config.Particles.LineLinked.Distance = float64(rand.Intn(300) + 100)
-		config.Particles.LineLinked.Opacity = 0.1 + rand.Float64()*0.9
-		config.Particles.LineLinked.Width = float64(rand.Intn(5) + 1)
+		config.Particles.LineLinked.Distance = float64(rng.Intn(300) + 100)
+		config.Particles.LineLinked.Opacity = 0.1 + rng.Float64()*0.9
+		config.Particles.LineLinked.Width = float64(rng.Intn(5) + 1)
 	}
 
 	// Randomize interactivity
-	config.Interactivity.Events.OnHover.Enable = rand.Intn(2) == 0
+	config.Interactivity.Events.OnHover.Enable = rng.Intn(2) == 0
 	hoverModes := []string{"grab", "bubble", "repulse"}
-	config.Interactivity.Events.OnHover.Mode = hoverModes[rand.Intn(len(hoverModes))]
+	config.Interactivity.Events.OnHover.Mode = hoverModes[rng.Intn(len(hoverModes))]
 
-	config.Interactivity.Events.OnClick.Enable = rand.Intn(2) == 0
+	config.Interactivity.Events.OnClick.Enable = rng.Intn(2) == 0
 	clickModes := []string{"push", "remove", "bubble", "repulse"}
-	config.Interactivity.Events.OnClick.Mode = clickModes[rand.Intn(len(clickModes))]
+	config.Interactivity.Events.OnClick.Mode = clickModes[rng.Intn(len(clickModes))]
 
 	return config
 }
@@ -0,0 +1,161 @@
+package physics
+
+import "math"
+
+// Boundary modes, what happens to planets at the canvas edges
+const (
+	// BoundaryReflect reverses the speed of planets past an edge, as
+	// gravity.js does. Planets aren't moved back, so they can drift
+	// further out while slowing down.
+	BoundaryReflect = "reflect"
+	// BoundaryBounce bounces planets off the edges, losing the share of
+	// speed EdgeRestitution leaves out, and keeps them on the canvas
+	BoundaryBounce = "bounce"
+	// BoundaryWrap moves planets leaving the canvas to the opposite edge
+	BoundaryWrap = "wrap"
+	// BoundaryDestroy removes planets that left the canvas
+	BoundaryDestroy = "destroy"
+	// BoundaryRespawn replaces planets that left the canvas with new ones
+	// orbiting the sun, moved onto the canvas if their orbit is off it
+	BoundaryRespawn = "respawn"
+	// BoundarySoft pushes planets back with a force growing as they get
+	// deeper into a margin along the edges
+	BoundarySoft = "soft"
+)
+
+// BoundaryModes returns the boundary modes, the default first
+func BoundaryModes() []string {
+	return []string{BoundaryReflect, BoundaryBounce, BoundaryWrap, BoundaryDestroy, BoundaryRespawn, BoundarySoft}
+}
+
+// BoundaryConfig represents the canvas edges
+type BoundaryConfig struct {
+	// Mode is one of BoundaryModes. Empty follows the configuration's
+	// particles.move.out_mode, or reflects like gravity.js.
+	Mode string `json:"mode"`
+	// Margin is the width of soft walls
+	Margin float64 `json:"margin"`
+	// Stiffness is the acceleration of a planet a pixel deep into a soft
+	// wall
+	Stiffness float64 `json:"stiffness"`
+}
+
+// bounceEdges bounces a planet touching an edge back onto the canvas
+func (w *World) bounceEdges(b *Body) {
+	e := w.Config.EdgeRestitution
+	b.X, b.VX = bounceAxis(b.X, b.VX, b.Radius, w.Width, e)
+	b.Y, b.VY = bounceAxis(b.Y, b.VY, b.Radius, w.Height, e)
+}
+
+// bounceAxis bounces a planet of radius r at x moving at v along an axis
+// of the given size, mirroring the distance it went past the edge
+func bounceAxis(x, v, r, size, e float64) (float64, float64) {
+	lo, hi := r, size-r
+	if lo > hi {
+		// The planet doesn't fit
+		return size / 2, 0
+	}
+	if x < lo {
+		x = lo + (lo-x)*e
+		if v < 0 {
+			v *= -e
+		}
+	} else if x > hi {
+		x = hi - (x-hi)*e
+		if v > 0 {
+			v *= -e
+		}
+	}
+	return clampAxis(x, r, size), v
+}
+
+// clampAxis moves a planet of radius r at x within an axis of the given
+// size, or to its middle when it doesn't fit
+func clampAxis(x, r, size float64) float64 {
+	if r > size-r {
+		return size / 2
+	}
+	return math.Max(r, math.Min(size-r, x))
+}
+
+// clampToCanvas moves a planet onto the canvas, leaving its speed alone
+func (w *World) clampToCanvas(b *Body) {
+	b.X = clampAxis(b.X, b.Radius, w.Width)
+	b.Y = clampAxis(b.Y, b.Radius, w.Height)
+}
+
+// wrap moves a planet that left the canvas to the opposite edge
+func (w *World) wrap(b *Body) {
+	if w.Width > 0 {
+		if b.X = math.Mod(b.X, w.Width); b.X < 0 {
+			b.X += w.Width
+		}
+	}
+	if w.Height > 0 {
+		if b.Y = math.Mod(b.Y, w.Height); b.Y < 0 {
+			b.Y += w.Height
+		}
+	}
+}
+
+// outside reports whether a planet is entirely off the canvas
+func (w *World) outside(b Body) bool {
+	return b.X < -b.Radius || b.X > w.Width+b.Radius || b.Y < -b.Radius || b.Y > w.Height+b.Radius
+}
+
+// leaveCanvas wraps, removes or replaces the planets that left the canvas
+// during a substep
+func (w *World) leaveCanvas() {
+	switch w.Config.Boundary.Mode {
+	case BoundaryWrap:
+		for i := range w.Bodies {
+			w.wrap(&w.Bodies[i])
+		}
+	case BoundaryDestroy:
+		bodies := w.Bodies[:0]
+		for _, b := range w.Bodies {
+			if w.outside(b) {
+				w.Exits++
+				continue
+			}
+			bodies = append(bodies, b)
+		}
+		w.Bodies = bodies
+	case BoundaryRespawn:
+		for i, b := range w.Bodies {
+			if w.outside(b) {
+				w.Exits++
+				// A small canvas may not hold the whole orbit
+				w.Bodies[i] = w.newPlanet()
+				w.clampToCanvas(&w.Bodies[i])
+			}
+		}
+	}
+}
+
+// softWalls returns the acceleration of the soft walls at x, y
+func (w *World) softWalls(x, y float64) (float64, float64) {
+	return softWall(x, w.Width, w.Config.Boundary), softWall(y, w.Height, w.Config.Boundary)
+}
+
+func softWall(x, size float64, c BoundaryConfig) float64 {
+	if x < c.Margin {
+		return c.Stiffness * (c.Margin - x)
+	}
+	if x > size-c.Margin {
+		return -c.Stiffness * (x - (size - c.Margin))
+	}
+	return 0
+}
+
+// softWallEnergy returns the potential energy per unit of mass stored by
+// a soft wall at x
+func softWallEnergy(x, size float64, c BoundaryConfig) float64 {
+	d := 0.0
+	if x < c.Margin {
+		d = c.Margin - x
+	} else if x > size-c.Margin {
+		d = x - (size - c.Margin)
+	}
+	return 0.5 * c.Stiffness * d * d
+}
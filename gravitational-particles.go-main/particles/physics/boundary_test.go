@@ -0,0 +1,114 @@
+package physics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBoundaryModes(t *testing.T) {
+	// A planet about to leave the right edge of a 1280 wide canvas
+	tests := []struct {
+		mode   string
+		x, vx  float64
+		exits  int
+		bodies int
+	}{
+		{"", 1290, -18, 0, 1},
+		{BoundaryReflect, 1290, -18, 0, 1},
+		{BoundaryBounce, 1278 - 12*0.9, -18, 0, 1},
+		{BoundaryWrap, 10, 20, 0, 1},
+		{BoundaryDestroy, 0, 0, 1, 0},
+		// 40 pixels into the 50 wide wall, pushed back at 0.02 per pixel
+		{BoundarySoft, 1270 + 19.2, 19.2, 0, 1},
+	}
+	for _, tt := range tests {
+		config := DefaultGravityConfig()
+		config.Boundary.Mode = tt.mode
+		w := oneBody(config, 1270, 400, 20, 0)
+		w.Step()
+
+		if len(w.Bodies) != tt.bodies || w.Exits != tt.exits {
+			t.Errorf("%q: %d planets after %d exits, want %d after %d", tt.mode, len(w.Bodies), w.Exits, tt.bodies, tt.exits)
+			continue
+		}
+		if tt.bodies == 0 {
+			continue
+		}
+		if b := w.Bodies[0]; !near(b.X, tt.x) || !near(b.VX, tt.vx) || b.Y != 400 {
+			t.Errorf("%q: planet at %v moving %v, want at %v moving %v", tt.mode, b.X, b.VX, tt.x, tt.vx)
+		}
+	}
+}
+
+func TestBoundaryRespawn(t *testing.T) {
+	config := DefaultGravityConfig()
+	config.Boundary.Mode = BoundaryRespawn
+	config.Planets.Count = 10
+	w := NewWorld(config, 1280, 800, 1)
+	w.Bodies[3].X, w.Bodies[3].VX = 1300, 50
+	w.Bodies[5].Y, w.Bodies[5].VY = -100, -50
+	w.Step()
+
+	if w.Exits != 2 || len(w.Bodies) != 10 {
+		t.Fatalf("%d planets after %d exits, want 10 after 2", len(w.Bodies), w.Exits)
+	}
+	ids := make(map[int]bool)
+	for i, b := range w.Bodies {
+		if w.outside(b) {
+			t.Errorf("planet %d left on %v, %v", i, b.X, b.Y)
+		}
+		if ids[b.ID] {
+			t.Errorf("ID %d used twice", b.ID)
+		}
+		ids[b.ID] = true
+	}
+	if !ids[10] || !ids[11] || ids[3] || ids[5] {
+		t.Errorf("got IDs %v, want 3 and 5 replaced by 10 and 11", ids)
+	}
+
+	// Canvases too small for an orbit still get their planets back on
+	w = NewWorld(config, 100, 80, 1)
+	w.Bodies[0].X = -50
+	w.Step()
+	if w.outside(w.Bodies[0]) {
+		t.Errorf("respawned planet off a small canvas at %v, %v", w.Bodies[0].X, w.Bodies[0].Y)
+	}
+}
+
+func TestBounceAxis(t *testing.T) {
+	tests := []struct {
+		x, v, r, size, e float64
+		x1, v1           float64
+	}{
+		{50, 3, 2, 100, 0.5, 50, 3},
+		{-4, -3, 2, 100, 0.5, 5, 1.5},
+		{104, 3, 2, 100, 0.5, 95, -1.5},
+		// Already heading back in
+		{1, 3, 2, 100, 0.5, 2.5, 3},
+		// Too large for the canvas
+		{10, 3, 60, 100, 0.5, 50, 0},
+	}
+	for _, tt := range tests {
+		x, v := bounceAxis(tt.x, tt.v, tt.r, tt.size, tt.e)
+		if !near(x, tt.x1) || !near(v, tt.v1) {
+			t.Errorf("bounceAxis(%v, %v, %v, %v, %v) = %v, %v, want %v, %v", tt.x, tt.v, tt.r, tt.size, tt.e, x, v, tt.x1, tt.v1)
+		}
+	}
+}
+
+// Soft walls store the energy they take, so bounces off them conserve it
+func TestSoftWallsConserveEnergy(t *testing.T) {
+	config := DefaultGravityConfig()
+	config.Boundary.Mode = BoundarySoft
+	config.Integrator = IntegratorVerlet
+	config.Substeps = 8
+	w := oneBody(config, 1200, 400, 3, 1)
+
+	r := MeasureDrift(w, 200)
+	if b := w.Bodies[0]; b.VX >= 0 || b.X > 1280 {
+		t.Fatalf("planet at %v moving %v, not turned back by the wall", b.X, b.VX)
+	}
+	if math.Abs(r.EnergyDrift) > 1e-3 {
+		t.Errorf("%v, want energy conserved", r)
+	}
+}
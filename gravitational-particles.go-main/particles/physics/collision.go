@@ -0,0 +1,275 @@
+package physics
+
+import "math"
+
+// Collision modes
+const (
+	// CollisionDiscrete checks the cursor against a planet's current and
+	// next positions, as gravity.js does. Fast planets can pass through.
+	CollisionDiscrete = "discrete"
+	// CollisionContinuous sweeps planets along their path during each step
+	// and bounces them at the exact time of impact
+	CollisionContinuous = "continuous"
+)
+
+// maxSweeps limits the bounces resolved for one body in one step
+const maxSweeps = 4
+
+// TimeOfImpact returns when two circles moving in straight lines first
+// touch during a step. dx, dy is the position of the first circle relative
+// to the second at the start of the step, mx, my the relative displacement
+// over the step and radius the sum of their radii. The time is a fraction
+// of the step; circles already overlapping and moving closer touch at 0.
+func TimeOfImpact(dx, dy, mx, my, radius float64) (float64, bool) {
+	a := mx*mx + my*my
+	b := dx*mx + dy*my
+	if a == 0 || b >= 0 {
+		// Not moving closer
+		return 0, false
+	}
+
+	c := dx*dx + dy*dy - radius*radius
+	if c <= 0 {
+		return 0, true
+	}
+
+	disc := b*b - a*c
+	if disc < 0 {
+		return 0, false
+	}
+	t := (-b - math.Sqrt(disc)) / a
+	if t > 1 {
+		return 0, false
+	}
+	return t, true
+}
+
+// obstacle is a circle moving in a straight line from x0, y0 to x1, y1
+// during a step. Bodies bounce off it as if it had infinite mass.
+type obstacle struct {
+	x0, y0      float64
+	x1, y1      float64
+	radius      float64
+	restitution float64
+	cursor      bool
+}
+
+// at returns the obstacle's position at fraction t of the step
+func (o obstacle) at(t float64) (float64, float64) {
+	return o.x0 + t*(o.x1-o.x0), o.y0 + t*(o.y1-o.y0)
+}
+
+// obstacles returns the obstacles planets collide with during the substep
+// running from frame fraction from to frame fraction to
+func (w *World) obstacles(from, to float64) []obstacle {
+	var obstacles []obstacle
+	c := w.Config
+	if c.Cursor.Enable && w.cursorActive {
+		o := obstacle{radius: c.Cursor.Radius, restitution: c.Collision.CursorRestitution, cursor: true}
+		// The cursor moves from where it was at the last step to where
+		// it is now over the course of the frame
+		o.x0 = w.prevCursorX + from*(w.cursorX-w.prevCursorX)
+		o.y0 = w.prevCursorY + from*(w.cursorY-w.prevCursorY)
+		o.x1 = w.prevCursorX + to*(w.cursorX-w.prevCursorX)
+		o.y1 = w.prevCursorY + to*(w.cursorY-w.prevCursorY)
+		obstacles = append(obstacles, o)
+	}
+	if c.Collision.Sun {
+		obstacles = append(obstacles, obstacle{
+			x0: w.Sun.X, y0: w.Sun.Y,
+			x1: w.Sun.X, y1: w.Sun.Y,
+			radius:      w.Sun.Radius,
+			restitution: c.Collision.SunRestitution,
+		})
+	}
+	return obstacles
+}
+
+// sweep moves a body that traveled from x0, y0 to its current position
+// during a step of length dt back to its first impact with an obstacle,
+// reflects its velocity there and moves it on for the rest of the step
+func (w *World) sweep(b *Body, x0, y0, dt float64, obstacles []obstacle) {
+	start := 0.0 // Fraction of the step already resolved
+	for i := 0; i < maxSweeps && start < 1; i++ {
+		mx, my := b.X-x0, b.Y-y0
+
+		// Find the first obstacle hit on the rest of the path
+		hit := -1
+		first := math.Inf(1)
+		for j, o := range obstacles {
+			ox0, oy0 := o.at(start)
+			ox1, oy1 := o.at(1)
+			t, ok := TimeOfImpact(x0-ox0, y0-oy0, mx-(ox1-ox0), my-(oy1-oy0), o.radius+b.Radius)
+			if ok && t < first {
+				hit, first = j, t
+			}
+		}
+		if hit < 0 {
+			return
+		}
+
+		o := obstacles[hit]
+		at := start + first*(1-start)
+		px, py := x0+first*mx, y0+first*my
+		ox, oy := o.at(at)
+
+		nx, ny, dist := normal(px-ox, py-oy, -b.VX, -b.VY)
+		if reach := o.radius + b.Radius; dist < reach {
+			// Overlapping already: put the body on the obstacle's surface
+			px, py = ox+nx*reach, oy+ny*reach
+		}
+
+		// Reflect the velocity relative to the obstacle
+		ovx, ovy := (o.x1-o.x0)/dt, (o.y1-o.y0)/dt
+		if vn := (b.VX-ovx)*nx + (b.VY-ovy)*ny; vn < 0 {
+			b.VX -= (1 + o.restitution) * vn * nx
+			b.VY -= (1 + o.restitution) * vn * ny
+			w.Collisions++
+			if o.cursor {
+				b.Flash = w.Config.Cursor.FlashSteps
+				w.Bounces++
+			}
+		}
+
+		x0, y0 = px, py
+		b.X = px + b.VX*(1-at)*dt
+		b.Y = py + b.VY*(1-at)*dt
+		start = at
+	}
+}
+
+// collideBodies bounces planets that met during a step of length dt off
+// each other, conserving momentum, or merges them in accretion mode.
+// starts holds their positions at the start of the step.
+func (w *World) collideBodies(starts []Body, dt float64) {
+	c := w.Config.Collision
+	w.gone = w.gone[:0]
+	for range w.Bodies {
+		w.gone = append(w.gone, false)
+	}
+
+	merged := false
+	for _, pair := range w.hash.candidates(starts, w.Bodies) {
+		i, j := pair[0], pair[1]
+		if w.gone[i] || w.gone[j] {
+			continue
+		}
+		a, b := &w.Bodies[i], &w.Bodies[j]
+		sa, sb := starts[i], starts[j]
+
+		mx := (a.X - sa.X) - (b.X - sb.X)
+		my := (a.Y - sa.Y) - (b.Y - sb.Y)
+		t, ok := TimeOfImpact(sa.X-sb.X, sa.Y-sb.Y, mx, my, a.Radius+b.Radius)
+		if !ok {
+			continue
+		}
+
+		// Positions at the time of impact
+		ax, ay := sa.X+t*(a.X-sa.X), sa.Y+t*(a.Y-sa.Y)
+		bx, by := sb.X+t*(b.X-sb.X), sb.Y+t*(b.Y-sb.Y)
+
+		if c.Merge {
+			merge(a, b, ax, ay, bx, by)
+			a.X += a.VX * (1 - t) * dt
+			a.Y += a.VY * (1 - t) * dt
+			w.gone[j] = true
+			merged = true
+			w.Merges++
+			continue
+		}
+
+		nx, ny, dist := normal(ax-bx, ay-by, b.VX-a.VX, b.VY-a.VY)
+		invA, invB := inverseMass(a.Mass), inverseMass(b.Mass)
+		if invA+invB == 0 {
+			continue
+		}
+
+		// Separate overlapping bodies in proportion to their inverse masses
+		if overlap := a.Radius + b.Radius - dist; overlap > 0 {
+			ax += nx * overlap * invA / (invA + invB)
+			ay += ny * overlap * invA / (invA + invB)
+			bx -= nx * overlap * invB / (invA + invB)
+			by -= ny * overlap * invB / (invA + invB)
+		}
+
+		if vn := (a.VX-b.VX)*nx + (a.VY-b.VY)*ny; vn < 0 {
+			impulse := -(1 + c.BodyRestitution) * vn / (invA + invB)
+			a.VX += impulse * invA * nx
+			a.VY += impulse * invA * ny
+			b.VX -= impulse * invB * nx
+			b.VY -= impulse * invB * ny
+			w.Collisions++
+		}
+
+		a.X, a.Y = ax+a.VX*(1-t)*dt, ay+a.VY*(1-t)*dt
+		b.X, b.Y = bx+b.VX*(1-t)*dt, by+b.VY*(1-t)*dt
+	}
+
+	if merged {
+		bodies := w.Bodies[:0]
+		for i, b := range w.Bodies {
+			if !w.gone[i] {
+				bodies = append(bodies, b)
+			}
+		}
+		w.Bodies = bodies
+	}
+}
+
+// merge turns a into the body formed by a at ax, ay accreting b at bx, by,
+// conserving mass and momentum. The merged body has the area of both and
+// keeps the ID and color of the heavier one.
+func merge(a *Body, b *Body, ax, ay, bx, by float64) {
+	mass := a.Mass + b.Mass
+	wa, wb := 0.5, 0.5
+	if mass > 0 {
+		wa, wb = a.Mass/mass, b.Mass/mass
+	}
+
+	merged := Body{
+		ID:     a.ID,
+		X:      wa*ax + wb*bx,
+		Y:      wa*ay + wb*by,
+		VX:     wa*a.VX + wb*b.VX,
+		VY:     wa*a.VY + wb*b.VY,
+		Radius: math.Hypot(a.Radius, b.Radius),
+		Mass:   mass,
+		Color:  a.Color,
+		Flash:  max(a.Flash, b.Flash),
+	}
+	if b.Mass > a.Mass {
+		merged.ID, merged.Color = b.ID, b.Color
+	}
+	// The merged planet lives as long as the shorter-lived of the two, so
+	// that merging never makes a planet immortal
+	for _, body := range []*Body{a, b} {
+		if body.Lifetime <= 0 {
+			continue
+		}
+		if merged.Lifetime <= 0 || body.Lifetime-body.Age < merged.Lifetime-merged.Age {
+			merged.Age, merged.Lifetime, merged.Fade = body.Age, body.Lifetime, body.Fade
+		}
+	}
+	*a = merged
+}
+
+// normal returns the unit vector along dx, dy and its length. For a zero
+// vector it falls back to the direction of fx, fy, or straight up.
+func normal(dx, dy, fx, fy float64) (float64, float64, float64) {
+	dist := math.Hypot(dx, dy)
+	if dist > 0 {
+		return dx / dist, dy / dist, dist
+	}
+	if f := math.Hypot(fx, fy); f > 0 {
+		return fx / f, fy / f, 0
+	}
+	return 0, -1, 0
+}
+
+// inverseMass treats bodies without mass as immovable
+func inverseMass(mass float64) float64 {
+	if mass <= 0 {
+		return 0
+	}
+	return 1 / mass
+}
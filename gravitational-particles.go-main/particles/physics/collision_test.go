@@ -0,0 +1,214 @@
+package physics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTimeOfImpact(t *testing.T) {
+	tests := []struct {
+		desc           string
+		dx, dy, mx, my float64
+		radius         float64
+		t              float64
+		ok             bool
+	}{
+		{"head on", 10, 0, -20, 0, 2, 0.4, true},
+		{"touching at the end", 10, 0, -8, 0, 2, 1, true},
+		{"diagonal", 3, 4, -6, -8, 0, 0.5, true},
+		{"after the step", 10, 0, -5, 0, 2, 0, false},
+		{"passing by", 10, 5, -20, 0, 2, 0, false},
+		{"grazing", 10, 2, -20, 0, 2, 0.5, true},
+		{"moving apart", 10, 0, 5, 0, 2, 0, false},
+		{"moving sideways", 10, 0, 0, 5, 2, 0, false},
+		{"not moving", 10, 0, 0, 0, 2, 0, false},
+		{"overlapping and closing", 1, 0, -1, 0, 2, 0, true},
+		{"overlapping and separating", 1, 0, 1, 0, 2, 0, false},
+		{"overlapping, not moving", 1, 0, 0, 0, 2, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := TimeOfImpact(tt.dx, tt.dy, tt.mx, tt.my, tt.radius)
+		if ok != tt.ok || math.Abs(got-tt.t) > 1e-12 {
+			t.Errorf("%s: got %v, %v, want %v, %v", tt.desc, got, ok, tt.t, tt.ok)
+		}
+	}
+}
+
+// oneBody returns a world without gravity and damping holding a single
+// planet at x, y moving by vx, vy every step
+func oneBody(config GravityConfig, x, y, vx, vy float64) *World {
+	config.Planets.Count = 0
+	config.ForceScale = 0
+	config.Damping = 1
+	w := NewWorld(config, 1280, 800, 1)
+	w.Bodies = []Body{{X: x, Y: y, VX: vx, VY: vy, Radius: 2, Mass: 1}}
+	return w
+}
+
+func TestContinuousSunCollision(t *testing.T) {
+	config := DefaultGravityConfig()
+	config.Collision.Mode = CollisionContinuous
+	config.Collision.Sun = true
+
+	// Fast enough to jump over the sun in one step
+	w := oneBody(config, 540, 400, 150, 0)
+	w.Step()
+	b := w.Bodies[0]
+	if b.VX != -150 || w.Collisions != 1 {
+		t.Fatalf("got velocity %v after %d collisions, want a bounce", b.VX, w.Collisions)
+	}
+	// It touches the sun at x 608, after 68 of its 150, and goes back 82
+	if want := 608.0 - 82; math.Abs(b.X-want) > 1e-9 {
+		t.Errorf("planet at %v, want %v", b.X, want)
+	}
+
+	config.Collision.Mode = CollisionDiscrete
+	w = oneBody(config, 540, 400, 150, 0)
+	w.Step()
+	if w.Bodies[0].X != 690 {
+		t.Errorf("discrete mode: planet at %v, want it through the sun", w.Bodies[0].X)
+	}
+}
+
+func TestContinuousCursorCollision(t *testing.T) {
+	config := DefaultGravityConfig()
+	config.Collision.Mode = CollisionContinuous
+	config.Cursor.Enable = true
+
+	// The cursor sweeps into a resting planet
+	w := oneBody(config, 200, 100, 0, 0)
+	w.SetCursor(100, 100)
+	w.SetCursor(200, 100)
+	w.Step()
+	b := w.Bodies[0]
+	if w.Bounces != 1 || b.Flash == 0 {
+		t.Fatalf("got %d bounces, flash %d", w.Bounces, b.Flash)
+	}
+	// An elastic bounce off a wall moving at 100 per step
+	if math.Abs(b.VX-200) > 1e-9 || b.X-200 < config.Cursor.Radius+b.Radius {
+		t.Errorf("planet at %v moving %v", b.X, b.VX)
+	}
+}
+
+func momentum(bodies []Body) (float64, float64, float64) {
+	var mass, px, py float64
+	for _, b := range bodies {
+		mass += b.Mass
+		px += b.Mass * b.VX
+		py += b.Mass * b.VY
+	}
+	return mass, px, py
+}
+
+func TestCollideBodies(t *testing.T) {
+	tests := []struct {
+		desc         string
+		restitution  float64
+		massA, massB float64
+		// Velocities along x before and after
+		vA, vB   float64
+		vA1, vB1 float64
+	}{
+		{"elastic, equal masses swap velocities", 1, 1, 1, 4, -4, -4, 4},
+		{"elastic, heavy and light", 1, 3, 1, 8, 0, 4, 12},
+		{"inelastic moves together", 0, 1, 1, 4, -4, 0, 0},
+		{"half elastic", 0.5, 1, 1, 4, -4, -2, 2},
+	}
+	for _, tt := range tests {
+		config := DefaultGravityConfig()
+		config.Collision.BodyRestitution = tt.restitution
+		w := oneBody(config, 0, 0, 0, 0)
+
+		// Heading at each other along x, touching halfway through the step
+		starts := []Body{
+			{ID: 0, X: 100, Y: 100, VX: tt.vA, Radius: 2, Mass: tt.massA},
+			{ID: 1, X: 104 + (tt.vA-tt.vB)/2, Y: 100, VX: tt.vB, Radius: 2, Mass: tt.massB},
+		}
+		w.Bodies = append([]Body(nil), starts...)
+		w.Bodies[0].X += tt.vA
+		w.Bodies[1].X += tt.vB
+		_, px, py := momentum(w.Bodies)
+		w.collideBodies(starts, 1)
+
+		if w.Collisions != 1 {
+			t.Errorf("%s: %d collisions", tt.desc, w.Collisions)
+			continue
+		}
+		_, qx, qy := momentum(w.Bodies)
+		if !near(px, qx) || !near(py, qy) {
+			t.Errorf("%s: momentum %v, %v, was %v, %v", tt.desc, qx, qy, px, py)
+		}
+		if a, b := w.Bodies[0], w.Bodies[1]; !near(a.VX, tt.vA1) || !near(b.VX, tt.vB1) {
+			t.Errorf("%s: velocities %v and %v, want %v and %v", tt.desc, a.VX, b.VX, tt.vA1, tt.vB1)
+		}
+		if gap := w.Bodies[1].X - w.Bodies[0].X; gap < 4-1e-9 {
+			t.Errorf("%s: bodies %v apart, overlapping", tt.desc, gap)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a := Body{ID: 1, X: 0, Y: 0, VX: 2, VY: 1, Radius: 3, Mass: 1, Color: "#a", Flash: 2}
+	b := Body{ID: 2, X: 6, Y: 0, VX: -1, VY: 0, Radius: 4, Mass: 2, Color: "#b", Flash: 5}
+	_, px, py := momentum([]Body{a, b})
+	merge(&a, &b, a.X, a.Y, b.X, b.Y)
+
+	if a.Mass != 3 || !near(a.VX*a.Mass, px) || !near(a.VY*a.Mass, py) {
+		t.Errorf("merged into %+v, not conserving mass and momentum", a)
+	}
+	if a.X != 4 || a.Radius != 5 || a.Flash != 5 {
+		t.Errorf("merged into %+v, want it at the center of mass with the area of both", a)
+	}
+	if a.ID != 2 || a.Color != "#b" {
+		t.Errorf("merged into %+v, want the heavier body's ID and color", a)
+	}
+}
+
+func TestMergeLifetime(t *testing.T) {
+	tests := []struct {
+		desc            string
+		a, b            Body
+		age, life, fade int
+	}{
+		{"both immortal", Body{}, Body{}, 0, 0, 0},
+		{"lighter one mortal", Body{Mass: 2}, Body{Mass: 1, Age: 5, Lifetime: 50, Fade: 10}, 5, 50, 10},
+		{"heavier one mortal", Body{Mass: 1}, Body{Mass: 2, Age: 5, Lifetime: 50, Fade: 10}, 5, 50, 10},
+		{"shorter remaining lifetime", Body{Mass: 2, Age: 10, Lifetime: 100, Fade: 20}, Body{Mass: 1, Age: 75, Lifetime: 80, Fade: 30}, 75, 80, 30},
+	}
+	for _, tt := range tests {
+		a, b := tt.a, tt.b
+		merge(&a, &b, 0, 0, 0, 0)
+		if a.Age != tt.age || a.Lifetime != tt.life || a.Fade != tt.fade {
+			t.Errorf("%s: got age %d, lifetime %d, fade %d, want %d, %d, %d", tt.desc, a.Age, a.Lifetime, a.Fade, tt.age, tt.life, tt.fade)
+		}
+	}
+}
+
+func TestAccretion(t *testing.T) {
+	config := DefaultGravityConfig()
+	config.Planets.Count = 150
+	config.Planets.MaxDistance = 120
+	config.Planets.SpeedFactor = 0.3
+	config.Collision.Bodies = true
+	config.Collision.Merge = true
+	config.Damping = 1
+	w := NewWorld(config, 1280, 800, 4)
+	mass, _, _ := momentum(w.Bodies)
+
+	for i := 0; i < 200; i++ {
+		w.Step()
+	}
+	if w.Merges == 0 {
+		t.Fatal("no planets merged")
+	}
+	if got, _, _ := momentum(w.Bodies); !near(got, mass) || len(w.Bodies)+w.Merges != 150 {
+		t.Errorf("%d planets of mass %v after %d merges, started with 150 of mass %v", len(w.Bodies), got, w.Merges, mass)
+	}
+	ids := make(map[int]bool)
+	for _, b := range w.Bodies {
+		if ids[b.ID] {
+			t.Errorf("ID %d used twice", b.ID)
+		}
+		ids[b.ID] = true
+	}
+}
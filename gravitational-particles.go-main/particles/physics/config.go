@@ -0,0 +1,188 @@
+package physics
+
+import "encoding/json"
+
+// GravityConfig parameterizes the sun-and-planets simulation of gravity.js.
+// Fields missing from JSON keep their DefaultGravityConfig values.
+type GravityConfig struct {
+	Sun     SunConfig    `json:"sun"`
+	Planets PlanetConfig `json:"planets"`
+	// ForceScale scales the sun's pull, mass / distance² * ForceScale
+	ForceScale float64 `json:"force_scale"`
+	// Damping multiplies planet velocities every step
+	Damping float64 `json:"damping"`
+	// EdgeRestitution is the share of speed kept when bouncing off the
+	// canvas edges
+	EdgeRestitution float64         `json:"edge_restitution"`
+	Boundary        BoundaryConfig  `json:"boundary"`
+	Cursor          CursorConfig    `json:"cursor"`
+	Collision       CollisionConfig `json:"collision"`
+	Mutual          MutualConfig    `json:"mutual"`
+	// Attractors pull planets like the sun does, or push them away
+	Attractors []AttractorConfig `json:"attractors,omitempty"`
+	// Fields accelerate planets wherever they are
+	Fields []FieldConfig `json:"fields,omitempty"`
+	// Emitters add planets as the simulation runs
+	Emitters []EmitterConfig `json:"emitters,omitempty"`
+	// MaxBodies stops emitters while there are this many planets, 0 for
+	// no limit; configurations with emitters must set one
+	MaxBodies int `json:"max_bodies"`
+	// Integrator names the numerical integrator: euler, verlet, leapfrog
+	// or rk4
+	Integrator string `json:"integrator"`
+	// Substeps splits every step into this many smaller ones
+	Substeps int          `json:"substeps"`
+	Warmup   WarmupConfig `json:"warmup"`
+	// State, when set, is where planets start instead of random orbits
+	State *State `json:"state,omitempty"`
+}
+
+// Limits on the strength of forces, so that a warm-up of MaxWarmupSteps
+// keeps planets at finite positions and speeds
+const (
+	// MaxMass bounds the mass of the sun, planets and attractors, either
+	// sign for attractors
+	MaxMass = 1e9
+	// MaxForceScale bounds ForceScale
+	MaxForceScale = 1e3
+	// MaxFieldAcceleration bounds the acceleration of fields
+	MaxFieldAcceleration = 1e3
+)
+
+// SunConfig represents the central attracting body
+type SunConfig struct {
+	Mass   float64 `json:"mass"`
+	Radius float64 `json:"radius"`
+	Color  string  `json:"color"`
+}
+
+// MaxPlanets is the most planets a configuration may start with or let
+// emitters add
+const MaxPlanets = 5000
+
+// PlanetConfig represents how planets are placed around the sun
+type PlanetConfig struct {
+	Count       int     `json:"count"`
+	MinDistance float64 `json:"min_distance"`
+	MaxDistance float64 `json:"max_distance"`
+	MinRadius   float64 `json:"min_radius"`
+	MaxRadius   float64 `json:"max_radius"`
+	Mass        float64 `json:"mass"`
+	// SpeedFactor scales the initial orbital speed, sqrt(sun mass / distance)
+	SpeedFactor float64  `json:"speed_factor"`
+	Colors      []string `json:"colors"`
+}
+
+// CursorConfig represents the cursor planets bounce off
+type CursorConfig struct {
+	Enable bool    `json:"enable"`
+	Radius float64 `json:"radius"`
+	// BounceFactor multiplies the speed of a planet bouncing off the cursor
+	// in discrete collision mode
+	BounceFactor float64 `json:"bounce_factor"`
+	// PushOut is the extra distance a bounced planet is moved clear of
+	// the cursor in discrete collision mode, so that it doesn't stick
+	PushOut float64 `json:"push_out"`
+	// FlashSteps is how many steps a bounced planet stays highlighted
+	FlashSteps int `json:"flash_steps"`
+}
+
+// CollisionConfig represents how planets collide
+type CollisionConfig struct {
+	// Mode is CollisionDiscrete or CollisionContinuous
+	Mode string `json:"mode"`
+	// CursorRestitution is the share of speed towards the cursor a planet
+	// keeps when bouncing off it in continuous mode
+	CursorRestitution float64 `json:"cursor_restitution"`
+	// Sun makes planets bounce off the sun in continuous mode instead of
+	// passing through it
+	Sun            bool    `json:"sun"`
+	SunRestitution float64 `json:"sun_restitution"`
+	// Bodies makes planets collide with each other, in either mode.
+	// Their paths are swept, so fast planets don't pass through.
+	Bodies bool `json:"bodies"`
+	// BodyRestitution is the share of their approach speed colliding
+	// planets keep: 1 is elastic, 0 leaves them moving together
+	BodyRestitution float64 `json:"body_restitution"`
+	// Merge makes colliding planets merge into one instead of bouncing,
+	// conserving mass and momentum
+	Merge bool `json:"merge"`
+}
+
+// MutualConfig represents the gravity planets exert on each other, scaled
+// by ForceScale like the sun's
+type MutualConfig struct {
+	// Mode is MutualOff, MutualDirect or MutualBarnesHut
+	Mode string `json:"mode"`
+	// Theta is the Barnes-Hut opening angle: groups of planets that look
+	// smaller than this from a planet pull it as one. 0 is exact.
+	Theta float64 `json:"theta"`
+	// Softening is added to distances so that planets passing close to
+	// each other don't get extreme forces
+	Softening float64 `json:"softening"`
+}
+
+// DefaultGravityConfig returns the settings of gravity.js
+func DefaultGravityConfig() GravityConfig {
+	return GravityConfig{
+		Sun: SunConfig{
+			Mass:   2000,
+			Radius: 30,
+			Color:  "#ffdd00",
+		},
+		Planets: PlanetConfig{
+			Count:       150,
+			MinDistance: 100,
+			MaxDistance: 250,
+			MinRadius:   2,
+			MaxRadius:   6,
+			Mass:        1,
+			SpeedFactor: 0.5,
+			Colors:      []string{"#ff7e7e", "#7eff8e", "#7ee0ff", "#ffffff"},
+		},
+		ForceScale:      0.1,
+		Damping:         0.999,
+		EdgeRestitution: 0.9,
+		Boundary: BoundaryConfig{
+			Margin:    50,
+			Stiffness: 0.02,
+		},
+		Cursor: CursorConfig{
+			Enable:       false,
+			Radius:       35,
+			BounceFactor: 1.5,
+			PushOut:      2,
+			FlashSteps:   10,
+		},
+		Collision: CollisionConfig{
+			Mode:              CollisionDiscrete,
+			CursorRestitution: 1,
+			SunRestitution:    1,
+			BodyRestitution:   1,
+		},
+		Mutual: MutualConfig{
+			Mode:      MutualOff,
+			Theta:     0.5,
+			Softening: 5,
+		},
+		MaxBodies:  2000,
+		Integrator: IntegratorEuler,
+		Substeps:   1,
+		Warmup: WarmupConfig{
+			Width:  1280,
+			Height: 800,
+			Seed:   1,
+		},
+	}
+}
+
+// UnmarshalJSON decodes a configuration on top of the defaults
+func (c *GravityConfig) UnmarshalJSON(data []byte) error {
+	type plain GravityConfig
+	config := plain(DefaultGravityConfig())
+	if err := json.Unmarshal(data, &config); err != nil {
+		return err
+	}
+	*c = GravityConfig(config)
+	return nil
+}
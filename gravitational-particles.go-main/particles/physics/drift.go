@@ -0,0 +1,124 @@
+package physics
+
+import (
+	"fmt"
+	"math"
+)
+
+// Diagnostics holds the conserved quantities of a world. The sun is fixed,
+// so linear momentum isn't conserved but angular momentum about the sun is.
+// Potential includes the pull between planets when mutual gravity is on,
+// attractors, uniform fields and soft walls. Moving attractors and
+// vortices add or take energy, so it is only conserved without them.
+type Diagnostics struct {
+	Kinetic   float64 `json:"kinetic"`
+	Potential float64 `json:"potential"`
+	Energy    float64 `json:"energy"`
+	MomentumX float64 `json:"momentum_x"`
+	MomentumY float64 `json:"momentum_y"`
+	// AngularMomentum is taken about the sun
+	AngularMomentum float64 `json:"angular_momentum"`
+}
+
+// Measure computes the world's energy and momentum
+func (w *World) Measure() Diagnostics {
+	var d Diagnostics
+	k := w.Config.ForceScale * w.Sun.Mass
+	for _, b := range w.Bodies {
+		rx, ry := b.X-w.Sun.X, b.Y-w.Sun.Y
+		d.Kinetic += 0.5 * b.Mass * (b.VX*b.VX + b.VY*b.VY)
+		if r := math.Hypot(rx, ry); r > 0 {
+			d.Potential -= b.Mass * k / r
+		}
+		d.MomentumX += b.Mass * b.VX
+		d.MomentumY += b.Mass * b.VY
+		d.AngularMomentum += b.Mass * (rx*b.VY - ry*b.VX)
+	}
+	for _, a := range w.Attractors() {
+		for _, b := range w.Bodies {
+			if r := math.Hypot(b.X-a.X, b.Y-a.Y); r > 0 {
+				d.Potential -= w.Config.ForceScale * a.Mass * b.Mass / r
+			}
+		}
+	}
+	if b := w.Config.Boundary; b.Mode == BoundarySoft {
+		for _, body := range w.Bodies {
+			d.Potential += body.Mass * (softWallEnergy(body.X, w.Width, b) + softWallEnergy(body.Y, w.Height, b))
+		}
+	}
+	for _, f := range w.Config.Fields {
+		if f.Type == FieldUniform {
+			for _, b := range w.Bodies {
+				d.Potential -= b.Mass * (f.AX*b.X + f.AY*b.Y)
+			}
+		}
+	}
+	if m := w.Config.Mutual; m.Mode == MutualDirect || m.Mode == MutualBarnesHut {
+		// The softened potential of every pair of planets
+		eps2 := m.Softening * m.Softening
+		for i, a := range w.Bodies {
+			for _, b := range w.Bodies[i+1:] {
+				dx, dy := a.X-b.X, a.Y-b.Y
+				if r := math.Sqrt(dx*dx + dy*dy + eps2); r > 0 {
+					d.Potential -= w.Config.ForceScale * a.Mass * b.Mass / r
+				}
+			}
+		}
+	}
+	d.Energy = d.Kinetic + d.Potential
+	return d
+}
+
+// DriftReport compares a world's conserved quantities before and after a
+// run. Drifts are relative to the initial values.
+type DriftReport struct {
+	Integrator string      `json:"integrator"`
+	Substeps   int         `json:"substeps"`
+	Steps      int         `json:"steps"`
+	Initial    Diagnostics `json:"initial"`
+	Final      Diagnostics `json:"final"`
+	// EnergyDrift is the relative change in total energy at the end
+	EnergyDrift float64 `json:"energy_drift"`
+	// MaxEnergyDrift is the largest relative change seen during the run
+	MaxEnergyDrift float64 `json:"max_energy_drift"`
+	// AngularMomentumDrift is the relative change in angular momentum
+	AngularMomentumDrift float64 `json:"angular_momentum_drift"`
+}
+
+// MeasureDrift runs the world for steps and reports how far energy and
+// angular momentum drifted. Only a world without damping, cursor and edge
+// bounces conserves them, so such a world shows the integrator's error.
+func MeasureDrift(w *World, steps int) DriftReport {
+	report := DriftReport{
+		Integrator: w.Config.Integrator,
+		Substeps:   w.substeps(),
+		Steps:      steps,
+		Initial:    w.Measure(),
+	}
+	if report.Integrator == "" {
+		report.Integrator = IntegratorEuler
+	}
+
+	for i := 0; i < steps; i++ {
+		w.Step()
+		drift := relativeChange(report.Initial.Energy, w.Measure().Energy)
+		report.MaxEnergyDrift = math.Max(report.MaxEnergyDrift, math.Abs(drift))
+	}
+
+	report.Final = w.Measure()
+	report.EnergyDrift = relativeChange(report.Initial.Energy, report.Final.Energy)
+	report.AngularMomentumDrift = relativeChange(report.Initial.AngularMomentum, report.Final.AngularMomentum)
+	return report
+}
+
+func (r DriftReport) String() string {
+	return fmt.Sprintf("%s x%d, %d steps: energy drift %.3e (max %.3e), angular momentum drift %.3e",
+		r.Integrator, r.Substeps, r.Steps, r.EnergyDrift, r.MaxEnergyDrift, r.AngularMomentumDrift)
+}
+
+func relativeChange(from, to float64) float64 {
+	if from == 0 {
+		return to - from
+	}
+	return (to - from) / math.Abs(from)
+}
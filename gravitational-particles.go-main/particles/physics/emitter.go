@@ -0,0 +1,153 @@
+package physics
+
+import "math"
+
+// Emitter shapes
+const (
+	// EmitterPoint emits planets at its position
+	EmitterPoint = "point"
+	// EmitterLine emits planets along the line from its position to To
+	EmitterLine = "line"
+	// EmitterArea emits planets in the rectangle between its position
+	// and To
+	EmitterArea = "area"
+)
+
+// MaxEmitterRate is the most planets an emitter may add per step
+const MaxEmitterRate = 100
+
+// EmitterConfig represents a source of new planets, for fountains, snow
+// falling from the top or comet tails
+type EmitterConfig struct {
+	// Shape is EmitterPoint, EmitterLine or EmitterArea
+	Shape    string `json:"shape"`
+	Position Point  `json:"position"`
+	To       Point  `json:"to,omitempty"`
+	// Path moves the emitter like an attractor
+	Path *PathConfig `json:"path,omitempty"`
+	// Rate is the number of planets emitted per step; fractions carry
+	// over to the next step
+	Rate float64 `json:"rate"`
+	// Burst planets are emitted at once every Interval steps, or once at
+	// the start when Interval is 0
+	Burst    int `json:"burst,omitempty"`
+	Interval int `json:"interval,omitempty"`
+	// Angle is the direction planets are emitted in, in degrees clockwise
+	// from the right, and Spread the width of the cone around it
+	Angle  float64 `json:"angle"`
+	Spread float64 `json:"spread"`
+	// Planets get a speed between MinSpeed and MaxSpeed
+	MinSpeed float64 `json:"min_speed"`
+	MaxSpeed float64 `json:"max_speed"`
+	// Planets get a radius between MinRadius and MaxRadius
+	MinRadius float64  `json:"min_radius"`
+	MaxRadius float64  `json:"max_radius"`
+	Mass      float64  `json:"mass"`
+	Colors    []string `json:"colors,omitempty"`
+	// Lifetime is the number of steps planets live, 0 for ever. They fade
+	// out over their last FadeOut steps.
+	Lifetime int `json:"lifetime,omitempty"`
+	FadeOut  int `json:"fade_out,omitempty"`
+}
+
+// Opacity returns how visible a planet is as it fades out at the end of
+// its life
+func (b Body) Opacity() float64 {
+	if b.Lifetime <= 0 || b.Fade <= 0 {
+		return 1
+	}
+	left := b.Lifetime - b.Age
+	if left >= b.Fade {
+		return 1
+	}
+	return math.Max(0, float64(left)/float64(b.Fade))
+}
+
+// emit adds the planets the emitters release during the substep of length
+// dt starting at time t, counted in steps
+func (w *World) emit(t, dt float64, first bool) {
+	for len(w.emitted) < len(w.Config.Emitters) {
+		w.emitted = append(w.emitted, 0)
+	}
+
+	for i, e := range w.Config.Emitters {
+		n := 0
+		if first && e.Burst > 0 {
+			if (e.Interval <= 0 && !w.burst) || (e.Interval > 0 && w.Steps%e.Interval == 0) {
+				n += e.Burst
+			}
+		}
+		w.emitted[i] += e.Rate * dt
+		whole := math.Floor(w.emitted[i])
+		w.emitted[i] -= whole
+		n += int(whole)
+
+		for ; n > 0 && w.room(); n-- {
+			w.Bodies = append(w.Bodies, w.emitPlanet(e, t))
+			w.Emitted++
+		}
+	}
+	if first {
+		w.burst = true
+	}
+}
+
+// room reports whether emitters may add another planet
+func (w *World) room() bool {
+	return w.Config.MaxBodies <= 0 || len(w.Bodies) < w.Config.MaxBodies
+}
+
+// emitPlanet returns a new planet from emitter e at time t
+func (w *World) emitPlanet(e EmitterConfig, t float64) Body {
+	origin := w.attractor(AttractorConfig{Position: e.Position, Path: e.Path}, t)
+	x, y := origin.X, origin.Y
+	toX, toY := e.To.resolve(w.Width, w.Height)
+	if e.Path != nil {
+		// The far end moves along with the emitter
+		px, py := e.Position.resolve(w.Width, w.Height)
+		toX, toY = toX+x-px, toY+y-py
+	}
+
+	switch e.Shape {
+	case EmitterLine:
+		f := w.rng.Float64()
+		x, y = x+f*(toX-x), y+f*(toY-y)
+	case EmitterArea:
+		x += w.rng.Float64() * (toX - x)
+		y += w.rng.Float64() * (toY - y)
+	}
+
+	angle := (e.Angle + (w.rng.Float64()-0.5)*e.Spread) * math.Pi / 180
+	speed := e.MinSpeed + w.rng.Float64()*(e.MaxSpeed-e.MinSpeed)
+	body := Body{
+		ID:       w.nextID,
+		X:        x,
+		Y:        y,
+		VX:       math.Cos(angle) * speed,
+		VY:       math.Sin(angle) * speed,
+		Radius:   e.MinRadius + w.rng.Float64()*(e.MaxRadius-e.MinRadius),
+		Mass:     e.Mass,
+		Lifetime: e.Lifetime,
+		Fade:     e.FadeOut,
+	}
+	if len(e.Colors) > 0 {
+		body.Color = e.Colors[int(w.rng.Float64()*float64(len(e.Colors)))]
+	}
+	w.nextID++
+	return body
+}
+
+// age makes planets a step older and removes those at the end of their
+// life
+func (w *World) age() {
+	bodies := w.Bodies[:0]
+	for _, b := range w.Bodies {
+		b.Age++
+		if b.Lifetime > 0 && b.Age >= b.Lifetime {
+			w.Expired++
+			continue
+		}
+		bodies = append(bodies, b)
+	}
+	w.Bodies = bodies
+}
@@ -0,0 +1,174 @@
+package physics
+
+import (
+	"math"
+	"testing"
+)
+
+// emitterWorld returns a world without planets or gravity where e emits
+func emitterWorld(e EmitterConfig, maxBodies, substeps int) *World {
+	config := DefaultGravityConfig()
+	config.Planets.Count = 0
+	config.ForceScale = 0
+	config.Emitters = []EmitterConfig{e}
+	config.MaxBodies = maxBodies
+	config.Substeps = substeps
+	return NewWorld(config, 1280, 800, 1)
+}
+
+func run(w *World, steps int) {
+	for i := 0; i < steps; i++ {
+		w.Step()
+	}
+}
+
+func TestEmitterRate(t *testing.T) {
+	tests := []struct {
+		desc      string
+		e         EmitterConfig
+		maxBodies int
+		substeps  int
+		steps     int
+		emitted   int
+	}{
+		{"whole rate", EmitterConfig{Rate: 3}, 0, 1, 10, 30},
+		{"fractions carry over", EmitterConfig{Rate: 0.25}, 0, 1, 10, 2},
+		{"whatever the substeps", EmitterConfig{Rate: 0.25}, 0, 4, 10, 2},
+		{"burst at the start", EmitterConfig{Burst: 5}, 0, 1, 10, 5},
+		{"burst every 3 steps", EmitterConfig{Burst: 5, Interval: 3}, 0, 1, 10, 20},
+		{"bursts once per step", EmitterConfig{Burst: 5, Interval: 3}, 0, 4, 10, 20},
+		{"max bodies", EmitterConfig{Rate: 10}, 15, 1, 5, 15},
+		{"no limit", EmitterConfig{Rate: 10}, 0, 1, 5, 50},
+	}
+	for _, tt := range tests {
+		w := emitterWorld(tt.e, tt.maxBodies, tt.substeps)
+		run(w, tt.steps)
+		if w.Emitted != tt.emitted || len(w.Bodies) != tt.emitted {
+			t.Errorf("%s: emitted %d, %d planets, want %d", tt.desc, w.Emitted, len(w.Bodies), tt.emitted)
+		}
+	}
+}
+
+func TestEmitterRestored(t *testing.T) {
+	e := EmitterConfig{Rate: 0.4, Burst: 5, MaxRadius: 2}
+	config := emitterWorld(e, 0, 1).Config
+	config.Warmup.Steps = 3
+
+	// A restored world emits what the original does next: no second
+	// one-off burst, and the fraction owed carried over
+	state := warmUp(t, config)
+	restored := NewWorld(withState(t, config, 3), 1280, 800, 1)
+	original := emitterWorld(e, 0, 1)
+	run(original, 3)
+	emitted := original.Emitted
+	run(restored, 4)
+	run(original, 4)
+	if got, want := restored.Emitted, original.Emitted-emitted; got != want {
+		t.Errorf("restored world emitted %d planets, want %d", got, want)
+	}
+	if len(restored.Bodies) != len(original.Bodies) || len(state.Bodies) != emitted {
+		t.Errorf("restored world has %d planets, want %d", len(restored.Bodies), len(original.Bodies))
+	}
+}
+
+func TestEmitterLifetime(t *testing.T) {
+	w := emitterWorld(EmitterConfig{Rate: 1, Lifetime: 3}, 0, 1)
+	run(w, 10)
+	// Every planet lives through three steps, the last removing it
+	if len(w.Bodies) != 2 || w.Expired != 8 || w.Emitted != 10 {
+		t.Errorf("%d planets after %d emitted and %d expired, want 2, 10 and 8", len(w.Bodies), w.Emitted, w.Expired)
+	}
+	for _, b := range w.Bodies {
+		if b.Age >= b.Lifetime {
+			t.Errorf("planet %d of age %d kept past its lifetime", b.ID, b.Age)
+		}
+	}
+
+	// Freed room is used again
+	w = emitterWorld(EmitterConfig{Rate: 5, Lifetime: 2}, 6, 1)
+	run(w, 10)
+	if w.Emitted <= 6 || len(w.Bodies) > 6 {
+		t.Errorf("emitted %d with %d planets, want room reused under 6", w.Emitted, len(w.Bodies))
+	}
+}
+
+func TestOpacity(t *testing.T) {
+	tests := []struct {
+		age, lifetime, fade int
+		want                float64
+	}{
+		{50, 0, 4, 1},
+		{5, 10, 0, 1},
+		{0, 10, 4, 1},
+		{6, 10, 4, 1},
+		{8, 10, 4, 0.5},
+		{9, 10, 4, 0.25},
+		{10, 10, 4, 0},
+		{12, 10, 4, 0},
+	}
+	for _, tt := range tests {
+		b := Body{Age: tt.age, Lifetime: tt.lifetime, Fade: tt.fade}
+		if got := b.Opacity(); got != tt.want {
+			t.Errorf("age %d of %d, fading over %d: got opacity %v, want %v", tt.age, tt.lifetime, tt.fade, got, tt.want)
+		}
+	}
+}
+
+func TestEmitterShapes(t *testing.T) {
+	base := EmitterConfig{
+		Rate:      20,
+		Position:  Point{X: 100, Y: 50},
+		To:        Point{X: 0.5, Y: 0.25, Relative: true},
+		Angle:     90,
+		MinSpeed:  2,
+		MaxSpeed:  2,
+		MinRadius: 1,
+		MaxRadius: 3,
+		Mass:      0.5,
+		Colors:    []string{"#fff"},
+	}
+	for _, shape := range []string{EmitterPoint, EmitterLine, EmitterArea} {
+		e := base
+		e.Shape = shape
+		w := emitterWorld(e, 0, 1)
+		w.emit(0, 1, true)
+		if len(w.Bodies) != 20 {
+			t.Fatalf("%s: emitted %d planets", shape, len(w.Bodies))
+		}
+		for _, b := range w.Bodies {
+			// Straight down at speed 2
+			if !near(b.VX, 0) || !near(b.VY, 2) || b.Radius < 1 || b.Radius > 3 || b.Mass != 0.5 || b.Color != "#fff" {
+				t.Errorf("%s: got planet %+v", shape, b)
+			}
+			switch shape {
+			case EmitterPoint:
+				if b.X != 100 || b.Y != 50 {
+					t.Errorf("point: planet at %v, %v", b.X, b.Y)
+				}
+			case EmitterLine:
+				// On the line from 100, 50 to 640, 200
+				if cross := (b.X-100)*150 - (b.Y-50)*540; math.Abs(cross) > 1e-6 || b.X < 100 || b.X > 640 {
+					t.Errorf("line: planet at %v, %v", b.X, b.Y)
+				}
+			case EmitterArea:
+				if b.X < 100 || b.X > 640 || b.Y < 50 || b.Y > 200 {
+					t.Errorf("area: planet at %v, %v", b.X, b.Y)
+				}
+			}
+		}
+	}
+}
+
+func TestEmitterIDs(t *testing.T) {
+	config := DefaultGravityConfig()
+	config.Planets.Count = 5
+	config.Emitters = []EmitterConfig{{Rate: 2}}
+	w := NewWorld(config, 1280, 800, 1)
+	run(w, 3)
+
+	for i, b := range w.Bodies {
+		if b.ID != i {
+			t.Errorf("planet %d has ID %d", i, b.ID)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package physics
+
+import "math"
+
+// Motion paths of attractors
+const (
+	// PathOrbit circles the attractor's position at Radius
+	PathOrbit = "orbit"
+	// PathLine swings the attractor back and forth between its position
+	// and To
+	PathLine = "line"
+)
+
+// Field types
+const (
+	// FieldUniform accelerates every planet the same way, like wind or
+	// falling snow
+	FieldUniform = "uniform"
+	// FieldVortex swirls planets around a center
+	FieldVortex = "vortex"
+)
+
+// Point is a position on the canvas. Relative points are fractions of the
+// canvas size, so that 0.5, 0.5 is the middle of any canvas.
+type Point struct {
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	Relative bool    `json:"relative,omitempty"`
+}
+
+// resolve returns the point in canvas coordinates
+func (p Point) resolve(width, height float64) (float64, float64) {
+	if p.Relative {
+		return p.X * width, p.Y * height
+	}
+	return p.X, p.Y
+}
+
+// AttractorConfig represents a body pulling planets like the sun, or
+// pushing them away when its mass is negative
+type AttractorConfig struct {
+	Position Point   `json:"position"`
+	Mass     float64 `json:"mass"`
+	// Radius is the size of the attractor; planets overlapping it aren't
+	// pulled, as with the sun
+	Radius float64     `json:"radius"`
+	Color  string      `json:"color,omitempty"`
+	Path   *PathConfig `json:"path,omitempty"`
+}
+
+// PathConfig represents how an attractor moves
+type PathConfig struct {
+	// Type is PathOrbit or PathLine
+	Type string `json:"type"`
+	// Radius is the radius of an orbit
+	Radius float64 `json:"radius,omitempty"`
+	// To is the far end of a line
+	To Point `json:"to,omitempty"`
+	// Period is the number of steps a loop takes
+	Period float64 `json:"period"`
+	// Phase is the fraction of a loop done at step 0
+	Phase float64 `json:"phase,omitempty"`
+}
+
+// FieldConfig represents a force acting on planets wherever they are
+type FieldConfig struct {
+	// Type is FieldUniform or FieldVortex
+	Type string `json:"type"`
+	// AX and AY are the acceleration of a uniform field, positive AY
+	// pointing down
+	AX float64 `json:"ax,omitempty"`
+	AY float64 `json:"ay,omitempty"`
+	// Center is the center of a vortex
+	Center Point `json:"center,omitempty"`
+	// Strength is the acceleration around a vortex, clockwise on screen
+	// when positive
+	Strength float64 `json:"strength,omitempty"`
+	// Radius is the core of a vortex. The swirl grows from its center to
+	// Radius and weakens with distance beyond, or is the same everywhere
+	// when Radius is 0.
+	Radius float64 `json:"radius,omitempty"`
+}
+
+// attractor returns a as a body at its position at time t, counted in
+// steps
+func (w *World) attractor(a AttractorConfig, t float64) Body {
+	x, y := a.Position.resolve(w.Width, w.Height)
+	if p := a.Path; p != nil && p.Period > 0 {
+		phase := 2 * math.Pi * (t/p.Period + p.Phase)
+		switch p.Type {
+		case PathOrbit:
+			x += p.Radius * math.Cos(phase)
+			y += p.Radius * math.Sin(phase)
+		case PathLine:
+			toX, toY := p.To.resolve(w.Width, w.Height)
+			f := (1 - math.Cos(phase)) / 2
+			x += f * (toX - x)
+			y += f * (toY - y)
+		}
+	}
+	return Body{X: x, Y: y, Radius: a.Radius, Mass: a.Mass, Color: a.Color}
+}
+
+// Attractors returns the attractors where they are at the current step
+func (w *World) Attractors() []Body {
+	return w.attractorsAt(float64(w.Steps), nil)
+}
+
+// attractorsAt appends the attractors at time t to dst
+func (w *World) attractorsAt(t float64, dst []Body) []Body {
+	for _, a := range w.Config.Attractors {
+		dst = append(dst, w.attractor(a, t))
+	}
+	return dst
+}
+
+// fieldAccel returns the acceleration of the fields at x, y
+func (w *World) fieldAccel(x, y float64) (float64, float64) {
+	var ax, ay float64
+	for _, f := range w.Config.Fields {
+		switch f.Type {
+		case FieldUniform:
+			ax += f.AX
+			ay += f.AY
+		case FieldVortex:
+			cx, cy := f.Center.resolve(w.Width, w.Height)
+			dx, dy := x-cx, y-cy
+			r := math.Hypot(dx, dy)
+			if r == 0 {
+				continue
+			}
+			strength := f.Strength
+			if f.Radius > 0 {
+				strength *= math.Min(r/f.Radius, f.Radius/r)
+			}
+			// Perpendicular to the radius; y points down on screen
+			ax += -dy / r * strength
+			ay += dx / r * strength
+		}
+	}
+	return ax, ay
+}
+
+// pullFrom returns the acceleration at x, y towards a body of the given
+// reach, or none when closer than reach
+func pullFrom(b Body, x, y, reach, scale float64) (float64, float64) {
+	dx := b.X - x
+	dy := b.Y - y
+	distSq := dx*dx + dy*dy
+	dist := math.Sqrt(distSq)
+	if dist <= reach {
+		return 0, 0
+	}
+	force := b.Mass / distSq * scale
+	return dx / dist * force, dy / dist * force
+}
@@ -0,0 +1,107 @@
+package physics
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestAttractorPaths(t *testing.T) {
+	config := DefaultGravityConfig()
+	config.Attractors = []AttractorConfig{
+		{Position: Point{X: 0.25, Y: 0.5, Relative: true}, Mass: 100},
+		{Position: Point{X: 100, Y: 100}, Mass: -50, Path: &PathConfig{Type: PathOrbit, Radius: 10, Period: 40}},
+		{Position: Point{X: 100, Y: 100}, Mass: 10, Path: &PathConfig{Type: PathLine, To: Point{X: 300, Y: 100}, Period: 40, Phase: 0.25}},
+	}
+	w := NewWorld(config, 800, 600, 1)
+
+	tests := []struct {
+		t    float64
+		i    int
+		x, y float64
+	}{
+		{0, 0, 200, 300},
+		{17, 0, 200, 300},
+		{0, 1, 110, 100},
+		{10, 1, 100, 110},
+		{20, 1, 90, 100},
+		{40, 1, 110, 100},
+		// A quarter of the way through its loop, the line is halfway
+		{0, 2, 200, 100},
+		{10, 2, 300, 100},
+		{30, 2, 100, 100},
+	}
+	for _, tt := range tests {
+		a := w.attractor(config.Attractors[tt.i], tt.t)
+		if !near(a.X, tt.x) || !near(a.Y, tt.y) {
+			t.Errorf("attractor %d at step %v: at %v, %v, want %v, %v", tt.i, tt.t, a.X, a.Y, tt.x, tt.y)
+		}
+	}
+	if got := w.Attractors(); len(got) != 3 || got[1].Mass != -50 {
+		t.Errorf("got attractors %+v", got)
+	}
+}
+
+func TestPullFrom(t *testing.T) {
+	b := Body{X: 10, Y: 0, Mass: 200}
+	if ax, ay := pullFrom(b, 0, 0, 5, 0.5); !near(ax, 1) || ay != 0 {
+		t.Errorf("got %v, %v, want 200 / 10² * 0.5 towards the body", ax, ay)
+	}
+	b.Mass = -200
+	if ax, _ := pullFrom(b, 0, 0, 5, 0.5); !near(ax, -1) {
+		t.Errorf("negative mass: got %v, want a push away", ax)
+	}
+	if ax, ay := pullFrom(b, 6, 0, 5, 0.5); ax != 0 || ay != 0 {
+		t.Errorf("within reach: got %v, %v, want none", ax, ay)
+	}
+}
+
+func TestFieldAccel(t *testing.T) {
+	config := DefaultGravityConfig()
+	config.Fields = []FieldConfig{
+		{Type: FieldUniform, AX: 0.5, AY: 0.1},
+		{Type: FieldVortex, Center: Point{X: 0.5, Y: 0.5, Relative: true}, Strength: 2, Radius: 100},
+	}
+	w := NewWorld(config, 800, 600, 1)
+
+	tests := []struct {
+		x, y   float64
+		ax, ay float64
+	}{
+		// At the center only the uniform field acts
+		{400, 300, 0.5, 0.1},
+		// Full strength at the core's edge, clockwise on screen
+		{500, 300, 0.5, 2.1},
+		{400, 400, -1.5, 0.1},
+		// Growing inside the core and weakening outside
+		{450, 300, 0.5, 1.1},
+		{600, 300, 0.5, 1.1},
+	}
+	for _, tt := range tests {
+		ax, ay := w.fieldAccel(tt.x, tt.y)
+		if !near(ax, tt.ax) || !near(ay, tt.ay) {
+			t.Errorf("at %v, %v: got %v, %v, want %v, %v", tt.x, tt.y, ax, ay, tt.ax, tt.ay)
+		}
+	}
+}
+
+// Static attractors and uniform fields are conservative, so a world with
+// them keeps its energy
+func TestFieldsConserveEnergy(t *testing.T) {
+	config := DefaultGravityConfig()
+	config.Damping = 1
+	config.Planets.Count = 20
+	config.Planets.SpeedFactor = math.Sqrt(config.ForceScale)
+	config.Integrator = IntegratorRK4
+	config.Substeps = 4
+	config.Attractors = []AttractorConfig{{Position: Point{X: 100, Y: 100}, Mass: 50, Radius: 10}}
+	config.Fields = []FieldConfig{{Type: FieldUniform, AY: 0.001}}
+
+	r := MeasureDrift(NewWorld(config, 1280, 800, 2), 300)
+	if r.MaxEnergyDrift > 1e-6 {
+		t.Errorf("%v, want energy conserved", r)
+	}
+}
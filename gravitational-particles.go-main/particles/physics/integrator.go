@@ -0,0 +1,140 @@
+package physics
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Names of the integrators
+const (
+	IntegratorEuler    = "euler"
+	IntegratorVerlet   = "verlet"
+	IntegratorLeapfrog = "leapfrog"
+	IntegratorRK4      = "rk4"
+)
+
+// MaxSubsteps is the largest number of substeps a configuration may ask for
+const MaxSubsteps = 64
+
+// AccelFunc returns the acceleration of a body at x, y
+type AccelFunc func(x, y float64) (ax, ay float64)
+
+// Integrator advances a body's position and velocity by dt under accel.
+// Time is measured in animation frames, so gravity.js takes steps of 1.
+//
+// impulse applies the velocity changes that aren't forces, such as damping
+// and cursor bounces. Integrators call it exactly once, as soon as the
+// velocity for the step is known.
+type Integrator interface {
+	Integrate(b *Body, dt float64, accel AccelFunc, impulse func(b *Body))
+}
+
+// SemiImplicitEuler updates the velocity first and moves the body with the
+// new velocity. It is what gravity.js does: cheap, and stable for orbits,
+// but only first order accurate.
+type SemiImplicitEuler struct{}
+
+// Integrate advances b by dt
+func (SemiImplicitEuler) Integrate(b *Body, dt float64, accel AccelFunc, impulse func(b *Body)) {
+	ax, ay := accel(b.X, b.Y)
+	b.VX += ax * dt
+	b.VY += ay * dt
+	impulse(b)
+	b.X += b.VX * dt
+	b.Y += b.VY * dt
+}
+
+// VelocityVerlet moves the body with its current velocity and acceleration,
+// then updates the velocity with the average of the old and new
+// accelerations. It is second order accurate and conserves energy well
+// over long runs, at two force evaluations per step.
+type VelocityVerlet struct{}
+
+// Integrate advances b by dt
+func (VelocityVerlet) Integrate(b *Body, dt float64, accel AccelFunc, impulse func(b *Body)) {
+	ax, ay := accel(b.X, b.Y)
+	b.X += b.VX*dt + 0.5*ax*dt*dt
+	b.Y += b.VY*dt + 0.5*ay*dt*dt
+	nx, ny := accel(b.X, b.Y)
+	b.VX += 0.5 * (ax + nx) * dt
+	b.VY += 0.5 * (ay + ny) * dt
+	impulse(b)
+}
+
+// Leapfrog drifts the body for half a step, kicks its velocity with the
+// acceleration at the midpoint and drifts for the other half. It is second
+// order accurate and symplectic like velocity Verlet, with a single force
+// evaluation per step.
+type Leapfrog struct{}
+
+// Integrate advances b by dt
+func (Leapfrog) Integrate(b *Body, dt float64, accel AccelFunc, impulse func(b *Body)) {
+	b.X += b.VX * dt / 2
+	b.Y += b.VY * dt / 2
+	ax, ay := accel(b.X, b.Y)
+	b.VX += ax * dt
+	b.VY += ay * dt
+	impulse(b)
+	b.X += b.VX * dt / 2
+	b.Y += b.VY * dt / 2
+}
+
+// RK4 is the classic fourth order Runge-Kutta method. It is the most
+// accurate per step but not symplectic, so energy slowly drifts over very
+// long runs, and costs four force evaluations per step.
+type RK4 struct{}
+
+// Integrate advances b by dt
+func (RK4) Integrate(b *Body, dt float64, accel AccelFunc, impulse func(b *Body)) {
+	x, y, vx, vy := b.X, b.Y, b.VX, b.VY
+
+	ax1, ay1 := accel(x, y)
+	x2, y2 := x+vx*dt/2, y+vy*dt/2
+	vx2, vy2 := vx+ax1*dt/2, vy+ay1*dt/2
+
+	ax2, ay2 := accel(x2, y2)
+	x3, y3 := x+vx2*dt/2, y+vy2*dt/2
+	vx3, vy3 := vx+ax2*dt/2, vy+ay2*dt/2
+
+	ax3, ay3 := accel(x3, y3)
+	x4, y4 := x+vx3*dt, y+vy3*dt
+	vx4, vy4 := vx+ax3*dt, vy+ay3*dt
+
+	ax4, ay4 := accel(x4, y4)
+
+	b.X = x + dt/6*(vx+2*vx2+2*vx3+vx4)
+	b.Y = y + dt/6*(vy+2*vy2+2*vy3+vy4)
+	b.VX = vx + dt/6*(ax1+2*ax2+2*ax3+ax4)
+	b.VY = vy + dt/6*(ay1+2*ay2+2*ay3+ay4)
+	impulse(b)
+}
+
+var integrators = map[string]Integrator{
+	IntegratorEuler:    SemiImplicitEuler{},
+	IntegratorVerlet:   VelocityVerlet{},
+	IntegratorLeapfrog: Leapfrog{},
+	IntegratorRK4:      RK4{},
+}
+
+// IntegratorNames returns the names accepted by IntegratorByName, sorted
+func IntegratorNames() []string {
+	names := make([]string, 0, len(integrators))
+	for name := range integrators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IntegratorByName returns the integrator with the given name; an empty
+// name selects semi-implicit Euler
+func IntegratorByName(name string) (Integrator, error) {
+	if name == "" {
+		name = IntegratorEuler
+	}
+	integrator, ok := integrators[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown integrator %q", name)
+	}
+	return integrator, nil
+}
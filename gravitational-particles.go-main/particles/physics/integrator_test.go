@@ -0,0 +1,93 @@
+package physics
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// conservativeWorld returns planets in circular orbits, without damping,
+// so that any drift in energy is the integrator's error
+func conservativeWorld(integrator string, substeps int) *World {
+	config := DefaultGravityConfig()
+	config.Damping = 1
+	config.Planets.Count = 30
+	// Circular orbits need the sun's pull to match, sqrt(ForceScale)
+	config.Planets.SpeedFactor = math.Sqrt(config.ForceScale)
+	config.Integrator = integrator
+	config.Substeps = substeps
+	return NewWorld(config, 1280, 800, 3)
+}
+
+func TestIntegratorDrift(t *testing.T) {
+	// The largest energy drift allowed over 1000 steps, about ten times
+	// what each integrator shows
+	tests := []struct {
+		integrator string
+		substeps   int
+		maxDrift   float64
+	}{
+		{IntegratorEuler, 1, 1e-3},
+		{IntegratorEuler, 4, 1e-4},
+		{IntegratorVerlet, 1, 1e-8},
+		{IntegratorVerlet, 4, 1e-10},
+		{IntegratorLeapfrog, 1, 1e-8},
+		{IntegratorLeapfrog, 4, 1e-10},
+		{IntegratorRK4, 1, 1e-9},
+		{IntegratorRK4, 4, 1e-12},
+	}
+	for _, tt := range tests {
+		r := MeasureDrift(conservativeWorld(tt.integrator, tt.substeps), 1000)
+		if r.Integrator != tt.integrator || r.Substeps != tt.substeps || r.Steps != 1000 {
+			t.Errorf("%s x%d: report for %s x%d, %d steps", tt.integrator, tt.substeps, r.Integrator, r.Substeps, r.Steps)
+		}
+		if r.MaxEnergyDrift > tt.maxDrift || math.Abs(r.EnergyDrift) > r.MaxEnergyDrift {
+			t.Errorf("%v, want energy drift below %.0e", r, tt.maxDrift)
+		}
+		// A central force conserves angular momentum whatever the integrator
+		if math.Abs(r.AngularMomentumDrift) > 1e-9 {
+			t.Errorf("%v, want no angular momentum drift", r)
+		}
+	}
+}
+
+func TestSubstepsReduceDrift(t *testing.T) {
+	for _, name := range IntegratorNames() {
+		one := MeasureDrift(conservativeWorld(name, 1), 500)
+		four := MeasureDrift(conservativeWorld(name, 4), 500)
+		if four.MaxEnergyDrift >= one.MaxEnergyDrift {
+			t.Errorf("%s: drift %.3e with 4 substeps, %.3e without", name, four.MaxEnergyDrift, one.MaxEnergyDrift)
+		}
+	}
+}
+
+func TestIntegratorByName(t *testing.T) {
+	if got, err := IntegratorByName(""); err != nil || got != (SemiImplicitEuler{}) {
+		t.Errorf("empty name: got %T, %v", got, err)
+	}
+	for _, name := range IntegratorNames() {
+		if _, err := IntegratorByName(name); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := IntegratorByName("midpoint"); err == nil || !strings.Contains(err.Error(), "midpoint") {
+		t.Errorf("unknown integrator: got %v", err)
+	}
+	if got := conservativeWorld("midpoint", 1).integrator; got != (SemiImplicitEuler{}) {
+		t.Errorf("unknown integrator name selected %T", got)
+	}
+}
+
+// The impulse is applied once per step, whatever the integrator
+func TestIntegratorImpulse(t *testing.T) {
+	accel := func(x, y float64) (float64, float64) { return -x, -y }
+	for _, name := range IntegratorNames() {
+		integrator, _ := IntegratorByName(name)
+		calls := 0
+		b := Body{X: 1, VY: 1}
+		integrator.Integrate(&b, 0.1, accel, func(b *Body) { calls++ })
+		if calls != 1 {
+			t.Errorf("%s: impulse applied %d times", name, calls)
+		}
+	}
+}
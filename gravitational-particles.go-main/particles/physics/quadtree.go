@@ -0,0 +1,189 @@
+package physics
+
+import "math"
+
+// Mutual gravity modes
+const (
+	// MutualOff leaves planets attracted by the sun alone
+	MutualOff = "off"
+	// MutualDirect sums the pull of every planet on every other, O(n²)
+	MutualDirect = "direct"
+	// MutualBarnesHut approximates distant groups of planets by their
+	// center of mass using a quadtree, O(n log n)
+	MutualBarnesHut = "barnes-hut"
+)
+
+// maxTreeDepth stops subdividing where bodies sit on top of each other
+const maxTreeDepth = 48
+
+// quadNode is a square region of a Quadtree
+type quadNode struct {
+	cx, cy, half float64 // Center and half the side length
+	mass         float64
+	comX, comY   float64 // Center of mass
+	count        int
+	body         int // Index of the only body in a leaf, -1 otherwise
+	children     [4]int32
+	leaf         bool
+}
+
+// Quadtree partitions bodies by position for Barnes-Hut force evaluation.
+// A tree can be rebuilt every step without allocating.
+type Quadtree struct {
+	nodes  []quadNode
+	bodies []Body
+	stack  []int32
+}
+
+// Build partitions bodies, replacing the previous contents of the tree.
+// The tree keeps using bodies, which must not change until the next Build.
+func (t *Quadtree) Build(bodies []Body) {
+	t.nodes = t.nodes[:0]
+	t.bodies = bodies
+	if len(bodies) == 0 {
+		return
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, b := range bodies {
+		minX, maxX = math.Min(minX, b.X), math.Max(maxX, b.X)
+		minY, maxY = math.Min(minY, b.Y), math.Max(maxY, b.Y)
+	}
+	half := math.Max(maxX-minX, maxY-minY)/2 + 1
+	t.nodes = append(t.nodes, quadNode{cx: (minX + maxX) / 2, cy: (minY + maxY) / 2, half: half, body: -1, leaf: true})
+
+	for i := range bodies {
+		t.insert(0, i, 0)
+	}
+}
+
+// insert adds body i to the subtree rooted at node n
+func (t *Quadtree) insert(n, i, depth int) {
+	b := t.bodies[i]
+	for {
+		node := &t.nodes[n]
+		node.add(b)
+
+		if node.leaf {
+			if node.count == 1 {
+				node.body = i
+				return
+			}
+			if depth >= maxTreeDepth {
+				// Coincident bodies share the leaf
+				node.body = -1
+				return
+			}
+			// Split the leaf and push its body down
+			other := node.body
+			node.leaf = false
+			node.body = -1
+			c := t.child(n, t.bodies[other].X, t.bodies[other].Y)
+			t.nodes[c].add(t.bodies[other])
+			t.nodes[c].body = other
+		}
+
+		n = t.child(n, b.X, b.Y)
+		depth++
+	}
+}
+
+// add counts b in the node's mass and center of mass
+func (node *quadNode) add(b Body) {
+	total := node.mass + b.Mass
+	if total > 0 {
+		node.comX = (node.comX*node.mass + b.X*b.Mass) / total
+		node.comY = (node.comY*node.mass + b.Y*b.Mass) / total
+	} else {
+		node.comX, node.comY = b.X, b.Y
+	}
+	node.mass = total
+	node.count++
+}
+
+// contains reports whether x, y lies in the node's square
+func (node *quadNode) contains(x, y float64) bool {
+	return math.Abs(x-node.cx) <= node.half && math.Abs(y-node.cy) <= node.half
+}
+
+// child returns the child of node n containing x, y, creating it
+func (t *Quadtree) child(n int, x, y float64) int {
+	node := t.nodes[n]
+	q := 0
+	cx, cy := node.cx-node.half/2, node.cy-node.half/2
+	if x >= node.cx {
+		q |= 1
+		cx = node.cx + node.half/2
+	}
+	if y >= node.cy {
+		q |= 2
+		cy = node.cy + node.half/2
+	}
+
+	if c := node.children[q]; c != 0 {
+		return int(c)
+	}
+	t.nodes = append(t.nodes, quadNode{cx: cx, cy: cy, half: node.half / 2, body: -1, leaf: true})
+	c := len(t.nodes) - 1
+	t.nodes[n].children[q] = int32(c)
+	return c
+}
+
+// Accel returns the acceleration at x, y due to the bodies in the tree,
+// leaving out body skip. Groups of bodies whose size seen from x, y is
+// less than theta are treated as a single body at their center of mass.
+// g scales the force and softening keeps it finite at short range.
+func (t *Quadtree) Accel(x, y float64, skip int, theta, softening, g float64) (float64, float64) {
+	if len(t.nodes) == 0 {
+		return 0, 0
+	}
+
+	var ax, ay float64
+	eps2 := softening * softening
+	stack := append(t.stack[:0], 0)
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		node := &t.nodes[n]
+		if node.mass == 0 || (node.leaf && node.count == 1 && node.body == skip) {
+			continue
+		}
+
+		dx, dy := node.comX-x, node.comY-y
+		distSq := dx*dx + dy*dy
+		if node.leaf || (!node.contains(x, y) && 4*node.half*node.half < theta*theta*distSq) {
+			d := distSq + eps2
+			f := g * node.mass / (d * math.Sqrt(d))
+			ax += dx * f
+			ay += dy * f
+			continue
+		}
+
+		for _, c := range node.children {
+			if c != 0 {
+				stack = append(stack, c)
+			}
+		}
+	}
+	t.stack = stack
+	return ax, ay
+}
+
+// DirectAccel returns the acceleration at x, y due to every body except
+// skip, summing their pulls one by one
+func DirectAccel(bodies []Body, x, y float64, skip int, softening, g float64) (float64, float64) {
+	var ax, ay float64
+	eps2 := softening * softening
+	for j := range bodies {
+		if j == skip {
+			continue
+		}
+		dx, dy := bodies[j].X-x, bodies[j].Y-y
+		d := dx*dx + dy*dy + eps2
+		f := g * bodies[j].Mass / (d * math.Sqrt(d))
+		ax += dx * f
+		ay += dy * f
+	}
+	return ax, ay
+}
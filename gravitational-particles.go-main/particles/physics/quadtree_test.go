@@ -0,0 +1,68 @@
+package physics
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// swarm returns a world of n planets spread over a ring wide enough that
+// larger swarms aren't denser
+func swarm(n int, mode string) *World {
+	config := DefaultGravityConfig()
+	config.Planets.Count = n
+	config.Planets.MaxDistance = config.Planets.MinDistance + 2*math.Sqrt(float64(n))*config.Planets.MaxRadius
+	config.Mutual.Mode = mode
+	config.Mutual.Theta = 0.5
+	config.Mutual.Softening = 5
+	return NewWorld(config, 1920, 1080, 1)
+}
+
+// forceError returns the RMS error of the Barnes-Hut accelerations of
+// bodies relative to the exact ones
+func forceError(bodies []Body, theta, softening float64) float64 {
+	var tree Quadtree
+	tree.Build(bodies)
+	var errSq, sumSq float64
+	for i, b := range bodies {
+		ax, ay := tree.Accel(b.X, b.Y, i, theta, softening, 1)
+		dx, dy := DirectAccel(bodies, b.X, b.Y, i, softening, 1)
+		errSq += (ax-dx)*(ax-dx) + (ay-dy)*(ay-dy)
+		sumSq += dx*dx + dy*dy
+	}
+	return math.Sqrt(errSq / sumSq)
+}
+
+func TestQuadtreeAccuracy(t *testing.T) {
+	for _, n := range []int{10, 500, 2000} {
+		bodies := swarm(n, MutualBarnesHut).Bodies
+		for _, theta := range []float64{0, 0.3, 0.5, 0.8, 1} {
+			// The error of approximating a group by its center of mass
+			// grows with the square of its angular size
+			tolerance := 1e-12 + 0.1*theta*theta
+			if got := forceError(bodies, theta, 5); got > tolerance {
+				t.Errorf("%d planets, theta %v: RMS error %.2e, want at most %.2e", n, theta, got, tolerance)
+			}
+		}
+	}
+}
+
+func benchmarkStep(b *testing.B, mode string) {
+	for _, n := range []int{100, 500, 1000, 2000, 5000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			w := swarm(n, mode)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				w.Step()
+			}
+		})
+	}
+}
+
+func BenchmarkStepDirect(b *testing.B) {
+	benchmarkStep(b, MutualDirect)
+}
+
+func BenchmarkStepBarnesHut(b *testing.B) {
+	benchmarkStep(b, MutualBarnesHut)
+}
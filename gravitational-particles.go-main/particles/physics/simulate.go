@@ -0,0 +1,95 @@
+package physics
+
+import "math"
+
+// Summary holds statistics of a headless run
+type Summary struct {
+	Steps int `json:"steps"`
+	// Bodies is the number of planets at the end
+	Bodies int `json:"bodies"`
+	// Escaped counts the planets outside the canvas at the end
+	Escaped int `json:"escaped"`
+	// Unbound counts the planets moving fast enough to escape the sun
+	Unbound    int `json:"unbound"`
+	Bounces    int `json:"bounces"`
+	Collisions int `json:"collisions"`
+	Merges     int `json:"merges"`
+	// Exits counts planets destroyed or respawned at the canvas edges
+	Exits int `json:"exits"`
+	// Emitted counts planets added by emitters, and Expired those removed
+	// at the end of their lifetime
+	Emitted int `json:"emitted"`
+	Expired int `json:"expired"`
+	// MaxSpeed is the highest planet speed seen at the end of a step
+	MaxSpeed float64     `json:"max_speed"`
+	Initial  Diagnostics `json:"initial"`
+	Final    Diagnostics `json:"final"`
+	// EnergyDrift is the relative change in total energy at the end
+	EnergyDrift float64 `json:"energy_drift"`
+	// MaxEnergyDrift is the largest relative change seen at the steps
+	// sampled during the run
+	MaxEnergyDrift float64 `json:"max_energy_drift"`
+}
+
+// Simulate runs w for steps and returns statistics of the run. Every
+// every-th step is sampled: its energy is measured, which takes O(n²) with
+// mutual gravity, and when out is not nil it is written to out along with
+// the initial state.
+func Simulate(w *World, steps, every int, out TrajectoryWriter) (Summary, error) {
+	if every < 1 {
+		every = 1
+	}
+	summary := Summary{Initial: w.Measure()}
+	bounces, collisions, merges, exits := w.Bounces, w.Collisions, w.Merges, w.Exits
+	emitted, expired := w.Emitted, w.Expired
+
+	if out != nil {
+		if err := out.WriteFrame(w.Steps, w.Bodies); err != nil {
+			return summary, err
+		}
+	}
+	for i := 1; i <= steps; i++ {
+		w.Step()
+		for _, b := range w.Bodies {
+			summary.MaxSpeed = math.Max(summary.MaxSpeed, math.Hypot(b.VX, b.VY))
+		}
+		if i%every != 0 && i != steps {
+			continue
+		}
+		drift := relativeChange(summary.Initial.Energy, w.Measure().Energy)
+		summary.MaxEnergyDrift = math.Max(summary.MaxEnergyDrift, math.Abs(drift))
+		if out != nil && i%every == 0 {
+			if err := out.WriteFrame(w.Steps, w.Bodies); err != nil {
+				return summary, err
+			}
+		}
+	}
+	if out != nil {
+		if err := out.Flush(); err != nil {
+			return summary, err
+		}
+	}
+
+	summary.Steps = steps
+	summary.Bodies = len(w.Bodies)
+	summary.Bounces = w.Bounces - bounces
+	summary.Collisions = w.Collisions - collisions
+	summary.Merges = w.Merges - merges
+	summary.Exits = w.Exits - exits
+	summary.Emitted = w.Emitted - emitted
+	summary.Expired = w.Expired - expired
+	summary.Final = w.Measure()
+	summary.EnergyDrift = relativeChange(summary.Initial.Energy, summary.Final.Energy)
+	k := w.Config.ForceScale * w.Sun.Mass
+	for _, b := range w.Bodies {
+		if b.X < 0 || b.X > w.Width || b.Y < 0 || b.Y > w.Height {
+			summary.Escaped++
+		}
+		// Positive orbital energy about the sun
+		r := math.Hypot(b.X-w.Sun.X, b.Y-w.Sun.Y)
+		if r > 0 && 0.5*(b.VX*b.VX+b.VY*b.VY) > k/r {
+			summary.Unbound++
+		}
+	}
+	return summary, nil
+}
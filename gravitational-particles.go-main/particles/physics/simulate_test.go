@@ -0,0 +1,53 @@
+package physics
+
+import (
+	"math"
+	"testing"
+)
+
+// frameRecorder keeps the steps of the frames written to it
+type frameRecorder struct {
+	steps   []int
+	flushed bool
+}
+
+func (r *frameRecorder) WriteFrame(step int, bodies []Body) error {
+	r.steps = append(r.steps, step)
+	return nil
+}
+
+func (r *frameRecorder) Flush() error {
+	r.flushed = true
+	return nil
+}
+
+func TestSimulateSamples(t *testing.T) {
+	config := DefaultGravityConfig()
+	config.Planets.Count = 20
+	w := NewWorld(config, 1280, 800, 1)
+
+	var out frameRecorder
+	summary, err := Simulate(w, 10, 4, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{0, 4, 8}
+	if len(out.steps) != len(want) {
+		t.Fatalf("wrote steps %v, want %v", out.steps, want)
+	}
+	for i := range want {
+		if out.steps[i] != want[i] {
+			t.Fatalf("wrote steps %v, want %v", out.steps, want)
+		}
+	}
+	if !out.flushed {
+		t.Error("trajectory not flushed")
+	}
+	if summary.Steps != 10 || summary.Bodies != 20 || w.Steps != 10 {
+		t.Errorf("got %d steps and %d planets", summary.Steps, summary.Bodies)
+	}
+	// The last step is always sampled, so the drift at the end counts
+	if summary.MaxEnergyDrift < math.Abs(summary.EnergyDrift) {
+		t.Errorf("max drift %v below final drift %v", summary.MaxEnergyDrift, summary.EnergyDrift)
+	}
+}
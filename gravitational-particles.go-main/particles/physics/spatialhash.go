@@ -0,0 +1,91 @@
+package physics
+
+import (
+	"math"
+	"sort"
+)
+
+// maxHashCells is the most cells a body is entered in; bodies sweeping
+// over more are checked against every other body instead
+const maxHashCells = 16
+
+type cellKey struct{ x, y int32 }
+
+// spatialHash finds the bodies that may meet during a step by sorting the
+// boxes they sweep into a grid, so that only bodies sharing a cell are
+// tested, in O(n) for evenly spread bodies instead of O(n²)
+type spatialHash struct {
+	cells map[cellKey][]int32
+	large []int32
+	pairs [][2]int32
+}
+
+// candidates returns the pairs of bodies whose paths from starts to
+// bodies come close enough to collide, sorted and without duplicates
+func (h *spatialHash) candidates(starts, bodies []Body) [][2]int32 {
+	if h.cells == nil || len(h.cells) > 4*len(bodies)+64 {
+		// Drop the cells of old positions now and then
+		h.cells = make(map[cellKey][]int32)
+	}
+	for key, cell := range h.cells {
+		h.cells[key] = cell[:0]
+	}
+	h.large = h.large[:0]
+	h.pairs = h.pairs[:0]
+
+	// Cells fit the largest body, so most bodies cover up to four
+	size := 1.0
+	for _, b := range bodies {
+		size = math.Max(size, 2*b.Radius)
+	}
+
+	for i, b := range bodies {
+		s := starts[i]
+		x0, x1 := cellRange(s.X, b.X, b.Radius, size)
+		y0, y1 := cellRange(s.Y, b.Y, b.Radius, size)
+		// Also catches bodies gone too far for the grid, or to NaN
+		if !((x1-x0+1)*(y1-y0+1) <= maxHashCells && math.Abs(x0)+math.Abs(y0) < 1e9) {
+			h.large = append(h.large, int32(i))
+			continue
+		}
+		for x := int32(x0); x <= int32(x1); x++ {
+			for y := int32(y0); y <= int32(y1); y++ {
+				key := cellKey{x, y}
+				cell := h.cells[key]
+				for _, j := range cell {
+					h.pairs = append(h.pairs, [2]int32{j, int32(i)})
+				}
+				h.cells[key] = append(cell, int32(i))
+			}
+		}
+	}
+	for _, i := range h.large {
+		for j := range bodies {
+			if j != int(i) {
+				h.pairs = append(h.pairs, [2]int32{int32(min(int(i), j)), int32(max(int(i), j))})
+			}
+		}
+	}
+
+	sort.Slice(h.pairs, func(a, b int) bool {
+		if h.pairs[a][0] != h.pairs[b][0] {
+			return h.pairs[a][0] < h.pairs[b][0]
+		}
+		return h.pairs[a][1] < h.pairs[b][1]
+	})
+	unique := h.pairs[:0]
+	for k, pair := range h.pairs {
+		if k == 0 || pair != h.pairs[k-1] {
+			unique = append(unique, pair)
+		}
+	}
+	h.pairs = unique
+	return h.pairs
+}
+
+// cellRange returns the first and last cells covered along one axis by a
+// body of radius r moving from a to b
+func cellRange(a, b, r, size float64) (float64, float64) {
+	lo, hi := math.Min(a, b)-r, math.Max(a, b)+r
+	return math.Floor(lo / size), math.Floor(hi / size)
+}
@@ -0,0 +1,53 @@
+package physics
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Every pair of bodies that meets during a step is a candidate
+func TestSpatialHashCandidates(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var starts, bodies []Body
+	for i := 0; i < 300; i++ {
+		s := Body{X: rng.Float64() * 800, Y: rng.Float64() * 600, Radius: 1 + rng.Float64()*5}
+		b := s
+		b.X += rng.NormFloat64() * 10
+		b.Y += rng.NormFloat64() * 10
+		starts = append(starts, s)
+		bodies = append(bodies, b)
+	}
+	// A body far faster than the others, swept against all of them
+	bodies[0].X, bodies[0].Y = starts[0].X+500, starts[0].Y+400
+
+	var h spatialHash
+	candidates := make(map[[2]int32]bool)
+	pairs := h.candidates(starts, bodies)
+	for k, pair := range pairs {
+		if pair[0] >= pair[1] || (k > 0 && !(pairs[k-1][0] < pair[0] || pairs[k-1][0] == pair[0] && pairs[k-1][1] < pair[1])) {
+			t.Fatalf("pairs not sorted and unique at %v", pair)
+		}
+		candidates[pair] = true
+	}
+
+	met := 0
+	for i := range bodies {
+		for j := i + 1; j < len(bodies); j++ {
+			a, b, sa, sb := bodies[i], bodies[j], starts[i], starts[j]
+			mx := (a.X - sa.X) - (b.X - sb.X)
+			my := (a.Y - sa.Y) - (b.Y - sb.Y)
+			if _, ok := TimeOfImpact(sa.X-sb.X, sa.Y-sb.Y, mx, my, a.Radius+b.Radius); ok {
+				met++
+				if !candidates[[2]int32{int32(i), int32(j)}] {
+					t.Errorf("bodies %d and %d meet but aren't candidates", i, j)
+				}
+			}
+		}
+	}
+	if met == 0 {
+		t.Fatal("no bodies met; the test checks nothing")
+	}
+	if len(pairs) > len(bodies)*len(bodies)/10 {
+		t.Errorf("%d candidates for %d bodies", len(pairs), len(bodies))
+	}
+}
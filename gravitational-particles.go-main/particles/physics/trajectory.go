@@ -0,0 +1,178 @@
+package physics
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Trajectory formats
+const (
+	// TrajectoryCSV writes a step,body,x,y,vx,vy row per body and frame,
+	// body being its ID
+	TrajectoryCSV = "csv"
+	// TrajectoryJSONLines writes a JSON object per frame
+	TrajectoryJSONLines = "jsonl"
+	// TrajectoryBinary writes little-endian float32 values, see
+	// BinaryTrajectoryWriter
+	TrajectoryBinary = "binary"
+)
+
+// TrajectoryFormats returns the formats accepted by NewTrajectoryWriter
+func TrajectoryFormats() []string {
+	return []string{TrajectoryCSV, TrajectoryJSONLines, TrajectoryBinary}
+}
+
+// TrajectoryWriter records the positions and velocities of bodies frame
+// by frame. Bodies are identified by their ID, as merging planets changes
+// their order.
+type TrajectoryWriter interface {
+	WriteFrame(step int, bodies []Body) error
+	// Flush writes buffered frames to the underlying writer
+	Flush() error
+}
+
+// NewTrajectoryWriter returns a writer of the given format
+func NewTrajectoryWriter(format string, w io.Writer) (TrajectoryWriter, error) {
+	switch format {
+	case TrajectoryCSV:
+		return NewCSVTrajectoryWriter(w), nil
+	case TrajectoryJSONLines:
+		return NewJSONLinesTrajectoryWriter(w), nil
+	case TrajectoryBinary:
+		return NewBinaryTrajectoryWriter(w), nil
+	}
+	return nil, fmt.Errorf("unknown trajectory format %q", format)
+}
+
+// CSVTrajectoryWriter writes trajectories as CSV with a header row
+type CSVTrajectoryWriter struct {
+	w      *csv.Writer
+	header bool
+	record []string
+}
+
+// NewCSVTrajectoryWriter returns a CSV writer
+func NewCSVTrajectoryWriter(w io.Writer) *CSVTrajectoryWriter {
+	return &CSVTrajectoryWriter{w: csv.NewWriter(w), record: make([]string, 6)}
+}
+
+// WriteFrame writes a row per body
+func (t *CSVTrajectoryWriter) WriteFrame(step int, bodies []Body) error {
+	if !t.header {
+		if err := t.w.Write([]string{"step", "body", "x", "y", "vx", "vy"}); err != nil {
+			return err
+		}
+		t.header = true
+	}
+	for _, b := range bodies {
+		t.record[0] = strconv.Itoa(step)
+		t.record[1] = strconv.Itoa(b.ID)
+		t.record[2] = strconv.FormatFloat(b.X, 'g', -1, 64)
+		t.record[3] = strconv.FormatFloat(b.Y, 'g', -1, 64)
+		t.record[4] = strconv.FormatFloat(b.VX, 'g', -1, 64)
+		t.record[5] = strconv.FormatFloat(b.VY, 'g', -1, 64)
+		if err := t.w.Write(t.record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes buffered rows
+func (t *CSVTrajectoryWriter) Flush() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+// JSONLinesTrajectoryWriter writes a line per frame such as
+// {"step":1,"bodies":[{"id":0,"x":1,"y":2,"vx":0.5,"vy":0}]}
+type JSONLinesTrajectoryWriter struct {
+	w     *bufio.Writer
+	enc   *json.Encoder
+	frame jsonFrame
+}
+
+type jsonFrame struct {
+	Step   int         `json:"step"`
+	Bodies []bodyState `json:"bodies"`
+}
+
+// bodyState is the part of a body a trajectory records
+type bodyState struct {
+	ID int     `json:"id"`
+	X  float64 `json:"x"`
+	Y  float64 `json:"y"`
+	VX float64 `json:"vx"`
+	VY float64 `json:"vy"`
+}
+
+// NewJSONLinesTrajectoryWriter returns a JSON Lines writer
+func NewJSONLinesTrajectoryWriter(w io.Writer) *JSONLinesTrajectoryWriter {
+	buf := bufio.NewWriter(w)
+	return &JSONLinesTrajectoryWriter{w: buf, enc: json.NewEncoder(buf)}
+}
+
+// WriteFrame writes a line holding every body
+func (t *JSONLinesTrajectoryWriter) WriteFrame(step int, bodies []Body) error {
+	t.frame.Step = step
+	t.frame.Bodies = t.frame.Bodies[:0]
+	for _, b := range bodies {
+		t.frame.Bodies = append(t.frame.Bodies, bodyState{b.ID, b.X, b.Y, b.VX, b.VY})
+	}
+	return t.enc.Encode(t.frame)
+}
+
+// Flush writes buffered lines
+func (t *JSONLinesTrajectoryWriter) Flush() error {
+	return t.w.Flush()
+}
+
+// binaryMagic starts binary trajectories, followed by a version byte
+const binaryMagic = "PGTR"
+
+// BinaryTrajectoryWriter writes trajectories compactly. The stream starts
+// with "PGTR" and the format version, 2. Each frame follows as a uint32
+// step and uint32 number of bodies, then for every body its uint32 ID and
+// x, y, vx, vy as float32, all little-endian.
+type BinaryTrajectoryWriter struct {
+	w      *bufio.Writer
+	header bool
+	buf    []byte
+}
+
+// NewBinaryTrajectoryWriter returns a binary writer
+func NewBinaryTrajectoryWriter(w io.Writer) *BinaryTrajectoryWriter {
+	return &BinaryTrajectoryWriter{w: bufio.NewWriter(w)}
+}
+
+// WriteFrame writes a frame
+func (t *BinaryTrajectoryWriter) WriteFrame(step int, bodies []Body) error {
+	if !t.header {
+		if _, err := t.w.WriteString(binaryMagic + "\x02"); err != nil {
+			return err
+		}
+		t.header = true
+	}
+
+	t.buf = binary.LittleEndian.AppendUint32(t.buf[:0], uint32(step))
+	t.buf = binary.LittleEndian.AppendUint32(t.buf, uint32(len(bodies)))
+	for _, b := range bodies {
+		t.buf = binary.LittleEndian.AppendUint32(t.buf, uint32(b.ID))
+		for _, v := range [4]float64{b.X, b.Y, b.VX, b.VY} {
+			t.buf = binary.LittleEndian.AppendUint32(t.buf, math.Float32bits(float32(v)))
+		}
+	}
+	_, err := t.w.Write(t.buf)
+	return err
+}
+
+// Flush writes buffered frames
+func (t *BinaryTrajectoryWriter) Flush() error {
+	return t.w.Flush()
+}
@@ -0,0 +1,95 @@
+package physics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+var trajectoryFrames = []struct {
+	step   int
+	bodies []Body
+}{
+	{0, []Body{{ID: 0, X: 1, Y: 2, VX: 0.5, VY: -0.25}, {ID: 3, X: 10.125, Y: 20, VX: 0, VY: 1}}},
+	{5, []Body{{ID: 3, X: 11, Y: 21, VX: -1, VY: 2}}},
+}
+
+func writeTrajectory(t *testing.T, format string) string {
+	t.Helper()
+	var b bytes.Buffer
+	w, err := NewTrajectoryWriter(format, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range trajectoryFrames {
+		if err := w.WriteFrame(f.step, f.bodies); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestCSVTrajectory(t *testing.T) {
+	want := "step,body,x,y,vx,vy\n" +
+		"0,0,1,2,0.5,-0.25\n" +
+		"0,3,10.125,20,0,1\n" +
+		"5,3,11,21,-1,2\n"
+	if got := writeTrajectory(t, TrajectoryCSV); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestJSONLinesTrajectory(t *testing.T) {
+	want := `{"step":0,"bodies":[{"id":0,"x":1,"y":2,"vx":0.5,"vy":-0.25},{"id":3,"x":10.125,"y":20,"vx":0,"vy":1}]}` + "\n" +
+		`{"step":5,"bodies":[{"id":3,"x":11,"y":21,"vx":-1,"vy":2}]}` + "\n"
+	if got := writeTrajectory(t, TrajectoryJSONLines); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestBinaryTrajectory(t *testing.T) {
+	data := []byte(writeTrajectory(t, TrajectoryBinary))
+	if string(data[:5]) != "PGTR\x02" {
+		t.Fatalf("got header %q", data[:5])
+	}
+	data = data[5:]
+
+	next := func() uint32 {
+		v := binary.LittleEndian.Uint32(data)
+		data = data[4:]
+		return v
+	}
+	for _, f := range trajectoryFrames {
+		if step, n := next(), next(); int(step) != f.step || int(n) != len(f.bodies) {
+			t.Fatalf("got frame %d of %d bodies, want %d of %d", step, n, f.step, len(f.bodies))
+		}
+		for _, b := range f.bodies {
+			if id := next(); int(id) != b.ID {
+				t.Errorf("step %d: got body %d, want %d", f.step, id, b.ID)
+			}
+			for _, want := range []float64{b.X, b.Y, b.VX, b.VY} {
+				if got := math.Float32frombits(next()); float64(got) != want {
+					t.Errorf("step %d, body %d: got %v, want %v", f.step, b.ID, got, want)
+				}
+			}
+		}
+	}
+	if len(data) != 0 {
+		t.Errorf("%d bytes after the last frame", len(data))
+	}
+}
+
+func TestNewTrajectoryWriter(t *testing.T) {
+	for _, format := range TrajectoryFormats() {
+		if _, err := NewTrajectoryWriter(format, &bytes.Buffer{}); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := NewTrajectoryWriter("xml", &bytes.Buffer{}); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
@@ -0,0 +1,71 @@
+package physics
+
+import (
+	"fmt"
+	"math"
+)
+
+// MaxWarmupSteps is the longest warm-up a configuration may ask for
+const MaxWarmupSteps = 10000
+
+// WarmupConfig represents how long to run a simulation before showing it,
+// so that it starts from a settled state instead of a random one
+type WarmupConfig struct {
+	// Steps is the number of animation frames to simulate, 0 for none
+	Steps int `json:"steps"`
+	// Width and Height are the size of the canvas simulated
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	// Seed places the planets, so every warm-up ends the same way
+	Seed int64 `json:"seed"`
+}
+
+// State represents the planets of a running simulation. Positions are
+// relative to the sun, so that a state fits canvases of any size.
+type State struct {
+	// Step is the number of steps taken to reach the state
+	Step   int    `json:"step"`
+	Bodies []Body `json:"bodies"`
+	// Emitted holds the fractions of planets emitters owe, and Burst
+	// whether one-off bursts were emitted, so that a restored simulation
+	// emits as the original would have
+	Emitted []float64 `json:"emitted,omitempty"`
+	Burst   bool      `json:"burst,omitempty"`
+}
+
+// State returns the current state of the world
+func (w *World) State() *State {
+	s := &State{
+		Step:    w.Steps,
+		Bodies:  make([]Body, len(w.Bodies)),
+		Emitted: append([]float64(nil), w.emitted...),
+		Burst:   w.burst,
+	}
+	for i, b := range w.Bodies {
+		b.X -= w.Sun.X
+		b.Y -= w.Sun.Y
+		b.Flash = 0
+		s.Bodies[i] = b
+	}
+	return s
+}
+
+// WarmUp runs the simulation config describes for its warm-up and returns
+// the state it settles in. The cursor stays away during the warm-up. It
+// fails if forces strong enough to overflow fling planets to infinity,
+// as such a state could neither be stored nor sent to browsers.
+func WarmUp(config GravityConfig) (*State, error) {
+	config.State = nil
+	w := NewWorld(config, config.Warmup.Width, config.Warmup.Height, config.Warmup.Seed)
+	for i := 0; i < config.Warmup.Steps; i++ {
+		w.Step()
+	}
+	for _, b := range w.Bodies {
+		for _, v := range []float64{b.X, b.Y, b.VX, b.VY} {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return nil, fmt.Errorf("warm-up diverged: planet %d reached %v", b.ID, v)
+			}
+		}
+	}
+	return w.State(), nil
+}
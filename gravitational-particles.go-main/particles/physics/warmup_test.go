@@ -0,0 +1,92 @@
+package physics
+
+import (
+	"strings"
+	"testing"
+)
+
+// warmUp is WarmUp for configurations that settle
+func warmUp(t *testing.T, config GravityConfig) *State {
+	t.Helper()
+	state, err := WarmUp(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+func TestWarmUp(t *testing.T) {
+	config := DefaultGravityConfig()
+	config.Planets.Count = 20
+	config.Warmup.Steps = 50
+
+	state := warmUp(t, config)
+	if state.Step != 50 || len(state.Bodies) != 20 {
+		t.Fatalf("got state at step %d with %d planets", state.Step, len(state.Bodies))
+	}
+
+	// A warm-up is the same as running the world, relative to the sun
+	w := NewWorld(config, config.Warmup.Width, config.Warmup.Height, config.Warmup.Seed)
+	for i := 0; i < 50; i++ {
+		w.Step()
+	}
+	b := w.Bodies[7]
+	if got := state.Bodies[7]; got.X != b.X-w.Sun.X || got.Y != b.Y-w.Sun.Y || got.VX != b.VX {
+		t.Errorf("got planet %+v, want %+v relative to the sun", got, b)
+	}
+
+	// An existing state doesn't change where the warm-up starts
+	config.State = &State{Step: 9}
+	if again := warmUp(t, config); again.Bodies[7] != state.Bodies[7] {
+		t.Error("warm-up depends on the configured state")
+	}
+}
+
+func TestWarmUpDiverges(t *testing.T) {
+	config := DefaultGravityConfig()
+	config.Planets.Count = 10
+	config.Sun.Mass = 1e308
+	config.ForceScale = 1e308
+	config.Warmup.Steps = 3
+
+	if state, err := WarmUp(config); err == nil || !strings.Contains(err.Error(), "diverged") {
+		t.Errorf("got state %+v, error %v, want a diverged warm-up", state, err)
+	}
+}
+
+func TestWorldFromState(t *testing.T) {
+	config := DefaultGravityConfig()
+	config.Planets.Count = 20
+	config.Warmup.Steps = 30
+	config.State = warmUp(t, config)
+
+	// States fit canvases of any size
+	w := NewWorld(config, 400, 300, 99)
+	if w.Steps != 30 || len(w.Bodies) != 20 {
+		t.Fatalf("got world at step %d with %d planets", w.Steps, len(w.Bodies))
+	}
+	if b := w.Bodies[0]; b.X != config.State.Bodies[0].X+200 || b.Y != config.State.Bodies[0].Y+150 {
+		t.Errorf("planet at %v, %v, not placed around the sun", b.X, b.Y)
+	}
+
+	// Planets added later don't reuse IDs
+	if next := w.newPlanet(); next.ID != 20 {
+		t.Errorf("new planet got ID %d, want 20", next.ID)
+	}
+
+	// Continuing from a state is the same as running on
+	config.State = nil
+	config.Warmup.Steps = 31
+	want := warmUp(t, config).Bodies[4]
+	restored := NewWorld(withState(t, config, 30), config.Warmup.Width, config.Warmup.Height, 5)
+	restored.Step()
+	if got := restored.State().Bodies[4]; got != want {
+		t.Errorf("got %+v after restoring, want %+v", got, want)
+	}
+}
+
+func withState(t *testing.T, config GravityConfig, steps int) GravityConfig {
+	config.Warmup.Steps = steps
+	config.State = warmUp(t, config)
+	return config
+}
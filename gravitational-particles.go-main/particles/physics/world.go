@@ -0,0 +1,361 @@
+// Package physics simulates the "Pure Gravity" sun-and-planets effect of
+// gravity.js, so that it can be tested, tuned and pre-computed server-side.
+// A World seeded with the same value always evolves the same way.
+package physics
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Body represents the sun or a planet
+type Body struct {
+	// ID tells planets apart as they merge and are added or removed
+	ID     int     `json:"id"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	VX     float64 `json:"vx"`
+	VY     float64 `json:"vy"`
+	Radius float64 `json:"radius"`
+	Mass   float64 `json:"mass"`
+	Color  string  `json:"color"`
+	// Flash counts down the steps a planet stays highlighted after
+	// bouncing off the cursor
+	Flash int `json:"flash,omitempty"`
+	// Age is the number of steps a planet has lived. Planets with a
+	// Lifetime are removed at its end, fading out over the last Fade
+	// steps.
+	Age      int `json:"age,omitempty"`
+	Lifetime int `json:"lifetime,omitempty"`
+	Fade     int `json:"fade,omitempty"`
+}
+
+// World represents a sun orbited by planets on a canvas
+type World struct {
+	Config GravityConfig
+	Width  float64
+	Height float64
+	Sun    Body
+	Bodies []Body
+	// Steps counts the steps taken
+	Steps int
+	// Bounces counts planets bounced off the cursor
+	Bounces int
+	// Collisions counts the bounces found by continuous collision
+	// detection, off the cursor and the sun, and between planets
+	Collisions int
+	// Merges counts planets merged into another in accretion mode
+	Merges int
+	// Exits counts planets that left the canvas in the destroy and
+	// respawn boundary modes
+	Exits int
+	// Emitted counts planets added by emitters
+	Emitted int
+	// Expired counts planets removed at the end of their lifetime
+	Expired int
+
+	integrator               Integrator
+	accumulator              float64
+	cursorX, cursorY         float64
+	prevCursorX, prevCursorY float64
+	cursorActive             bool
+	starts                   []Body
+	tree                     Quadtree
+	pullX, pullY             []float64 // Mutual gravity on each planet
+	attractors               []Body    // Where attractors are this substep
+	hash                     spatialHash
+	gone                     []bool // Planets merged away this substep
+	rng                      *rand.Rand
+	nextID                   int
+	emitted                  []float64 // Fractions of planets owed by emitters
+	burst                    bool      // Whether one-off bursts were emitted
+}
+
+// NewWorld places the sun in the middle of a width x height canvas and
+// the planets in circular orbits around it, drawing their positions, sizes
+// and colors from a random source seeded with seed. When the configuration
+// has a State the planets start from it instead. An unknown integrator
+// name selects semi-implicit Euler.
+func NewWorld(config GravityConfig, width, height float64, seed int64) *World {
+	integrator, err := IntegratorByName(config.Integrator)
+	if err != nil {
+		integrator = SemiImplicitEuler{}
+	}
+
+	w := &World{
+		Config:     config,
+		integrator: integrator,
+		Width:      width,
+		Height:     height,
+		Sun: Body{
+			X:      width / 2,
+			Y:      height / 2,
+			Radius: config.Sun.Radius,
+			Mass:   config.Sun.Mass,
+			Color:  config.Sun.Color,
+		},
+	}
+
+	w.rng = rand.New(rand.NewSource(seed))
+	if config.State != nil {
+		w.Steps = config.State.Step
+		w.Bodies = make([]Body, len(config.State.Bodies))
+		for i, b := range config.State.Bodies {
+			b.X += w.Sun.X
+			b.Y += w.Sun.Y
+			w.Bodies[i] = b
+			w.nextID = max(w.nextID, b.ID+1)
+		}
+		w.emitted = append([]float64(nil), config.State.Emitted...)
+		w.burst = config.State.Burst
+		return w
+	}
+
+	w.Bodies = make([]Body, 0, config.Planets.Count)
+	for i := 0; i < config.Planets.Count; i++ {
+		w.Bodies = append(w.Bodies, w.newPlanet())
+	}
+
+	return w
+}
+
+// newPlanet places a planet in a random circular orbit around the sun
+func (w *World) newPlanet() Body {
+	p := w.Config.Planets
+
+	// Draw in the order gravity.js does
+	angle := w.rng.Float64() * math.Pi * 2
+	distance := p.MinDistance + w.rng.Float64()*(p.MaxDistance-p.MinDistance)
+	speed := math.Sqrt(w.Sun.Mass/distance) * p.SpeedFactor
+
+	body := Body{
+		ID:     w.nextID,
+		X:      w.Sun.X + math.Cos(angle)*distance,
+		Y:      w.Sun.Y + math.Sin(angle)*distance,
+		VX:     math.Sin(angle) * speed,
+		VY:     -math.Cos(angle) * speed,
+		Radius: p.MinRadius + w.rng.Float64()*(p.MaxRadius-p.MinRadius),
+		Mass:   p.Mass,
+	}
+	if len(p.Colors) > 0 {
+		body.Color = p.Colors[int(w.rng.Float64()*float64(len(p.Colors)))]
+	}
+	w.nextID++
+	return body
+}
+
+// SetCursor moves the cursor to x, y on the canvas. In continuous
+// collision mode the cursor sweeps from its last position to x, y during
+// the next step.
+func (w *World) SetCursor(x, y float64) {
+	if !w.cursorActive {
+		w.prevCursorX, w.prevCursorY = x, y
+	}
+	w.cursorX, w.cursorY = x, y
+	w.cursorActive = true
+}
+
+// ClearCursor removes the cursor, as when it leaves the canvas
+func (w *World) ClearCursor() {
+	w.cursorActive = false
+}
+
+// Resize changes the canvas size. Like gravity.js it leaves the sun and
+// planets where they are, except that planets left outside the canvas are
+// moved back in by the bounce and wrap boundary modes.
+func (w *World) Resize(width, height float64) {
+	w.Width, w.Height = width, height
+	for i := range w.Bodies {
+		switch w.Config.Boundary.Mode {
+		case BoundaryBounce:
+			w.clampToCanvas(&w.Bodies[i])
+		case BoundaryWrap:
+			w.wrap(&w.Bodies[i])
+		}
+	}
+}
+
+// Step advances the simulation by one animation frame, split into the
+// configured number of substeps
+func (w *World) Step() {
+	substeps := w.substeps()
+	dt := 1 / float64(substeps)
+	damping := math.Pow(w.Config.Damping, dt)
+	continuous := w.Config.Collision.Mode == CollisionContinuous
+	collide := w.Config.Collision.Bodies
+	mutual := w.Config.Mutual.Mode == MutualDirect || w.Config.Mutual.Mode == MutualBarnesHut
+	for i := 0; i < substeps; i++ {
+		if len(w.Config.Emitters) > 0 {
+			w.emit(float64(w.Steps)+float64(i)*dt, dt, i == 0)
+		}
+		var obstacles []obstacle
+		if continuous {
+			obstacles = w.obstacles(float64(i)*dt, float64(i+1)*dt)
+		}
+		if continuous || collide || mutual {
+			w.starts = append(w.starts[:0], w.Bodies...)
+		}
+		w.pull(w.starts)
+		w.attractors = w.attractorsAt(float64(w.Steps)+float64(i)*dt, w.attractors[:0])
+		for j := range w.Bodies {
+			w.stepBody(j, dt, damping, obstacles)
+		}
+		if collide {
+			w.collideBodies(w.starts, dt)
+		}
+		w.leaveCanvas()
+	}
+
+	for i := range w.Bodies {
+		if w.Bodies[i].Flash > 0 {
+			w.Bodies[i].Flash--
+		}
+	}
+	w.age()
+	w.prevCursorX, w.prevCursorY = w.cursorX, w.cursorY
+	w.Steps++
+}
+
+// pull computes the acceleration of every planet due to the others at
+// the start of a substep. It stays the same during the substep so that
+// planets pull each other equally whatever the integrator, which makes
+// mutual gravity first order accurate; substeps make it more precise.
+func (w *World) pull(starts []Body) {
+	w.pullX, w.pullY = w.pullX[:0], w.pullY[:0]
+	m := w.Config.Mutual
+	if m.Mode != MutualDirect && m.Mode != MutualBarnesHut {
+		return
+	}
+	if m.Mode == MutualBarnesHut {
+		w.tree.Build(starts)
+	}
+
+	for i, b := range starts {
+		var ax, ay float64
+		if m.Mode == MutualBarnesHut {
+			ax, ay = w.tree.Accel(b.X, b.Y, i, m.Theta, m.Softening, w.Config.ForceScale)
+		} else {
+			ax, ay = DirectAccel(starts, b.X, b.Y, i, m.Softening, w.Config.ForceScale)
+		}
+		w.pullX = append(w.pullX, ax)
+		w.pullY = append(w.pullY, ay)
+	}
+}
+
+// Advance runs the simulation for a number of frames that need not be
+// whole, as when following the display's frame times. Leftover time is
+// carried over to the next call, so steps always have the same length.
+// It returns the number of steps taken.
+func (w *World) Advance(frames float64) int {
+	w.accumulator += frames
+	steps := 0
+	for w.accumulator >= 1 {
+		w.Step()
+		w.accumulator--
+		steps++
+	}
+	return steps
+}
+
+func (w *World) substeps() int {
+	if w.Config.Substeps < 1 {
+		return 1
+	}
+	return w.Config.Substeps
+}
+
+// stepBody advances planet i by dt, in the order gravity.js's animation
+// loop updates it. In continuous collision mode it then bounces the planet
+// off the first of obstacles in its path.
+func (w *World) stepBody(i int, dt, damping float64, obstacles []obstacle) {
+	c := w.Config
+	b := &w.Bodies[i]
+
+	// Pull towards the sun, skipped when overlapping it to avoid
+	// extreme forces, towards the attractors and other planets, and
+	// along the fields
+	reach := w.Sun.Radius + b.Radius
+	accel := func(x, y float64) (float64, float64) {
+		ax, ay := pullFrom(w.Sun, x, y, reach, c.ForceScale)
+		for _, a := range w.attractors {
+			px, py := pullFrom(a, x, y, a.Radius+b.Radius, c.ForceScale)
+			ax, ay = ax+px, ay+py
+		}
+		if len(c.Fields) > 0 {
+			fx, fy := w.fieldAccel(x, y)
+			ax, ay = ax+fx, ay+fy
+		}
+		if c.Boundary.Mode == BoundarySoft {
+			sx, sy := w.softWalls(x, y)
+			ax, ay = ax+sx, ay+sy
+		}
+		if len(w.pullX) > i {
+			ax, ay = ax+w.pullX[i], ay+w.pullY[i]
+		}
+		return ax, ay
+	}
+
+	impulse := func(b *Body) {
+		if c.Cursor.Enable && w.cursorActive && c.Collision.Mode != CollisionContinuous {
+			w.bounceCursor(b, dt)
+		}
+		b.VX *= damping
+		b.VY *= damping
+	}
+
+	x0, y0 := b.X, b.Y
+	w.integrator.Integrate(b, dt, accel, impulse)
+	if len(obstacles) > 0 {
+		w.sweep(b, x0, y0, dt, obstacles)
+	}
+
+	switch c.Boundary.Mode {
+	case BoundaryBounce:
+		w.bounceEdges(b)
+	case BoundaryReflect, "":
+		// Bounce off the edges
+		if b.X < 0 || b.X > w.Width {
+			b.VX *= -c.EdgeRestitution
+		}
+		if b.Y < 0 || b.Y > w.Height {
+			b.VY *= -c.EdgeRestitution
+		}
+	}
+}
+
+// bounceCursor reflects a planet that touches the cursor or will touch it
+// after moving for dt, so fast planets don't pass through it
+func (w *World) bounceCursor(b *Body, dt float64) {
+	c := w.Config.Cursor
+
+	dx := w.cursorX - b.X
+	dy := w.cursorY - b.Y
+	dist := math.Sqrt(dx*dx + dy*dy)
+	nextDX := w.cursorX - (b.X + b.VX*dt)
+	nextDY := w.cursorY - (b.Y + b.VY*dt)
+	nextDist := math.Sqrt(nextDX*nextDX + nextDY*nextDY)
+
+	reach := c.Radius + b.Radius
+	if (dist >= reach && nextDist >= reach) || dist == 0 {
+		return
+	}
+
+	nx := dx / dist
+	ny := dy / dist
+	dot := b.VX*nx + b.VY*ny
+	// Only bounce planets moving towards the cursor
+	if dot >= 0 {
+		return
+	}
+
+	b.VX = (b.VX - 2*dot*nx) * c.BounceFactor
+	b.VY = (b.VY - 2*dot*ny) * c.BounceFactor
+
+	// Move the planet clear of the cursor so it doesn't stick
+	push := reach - dist + c.PushOut
+	b.X -= nx * push
+	b.Y -= ny * push
+
+	b.Flash = c.FlashSteps
+	w.Bounces++
+}
@@ -0,0 +1,72 @@
+package physics
+
+import (
+	"math"
+	"testing"
+)
+
+// Golden positions after 600 steps of the gravity.js defaults. Changing
+// the order in which a step updates planets, which follows gravity.js,
+// moves them.
+func TestWorldReference(t *testing.T) {
+	w := NewWorld(DefaultGravityConfig(), 1280, 800, 42)
+	w.SetCursor(640, 250)
+	for i := 0; i < 600; i++ {
+		w.Step()
+	}
+
+	if b := w.Bodies[0]; b.X != 1140.5659105749555 || b.Y != 542.649314992455 {
+		t.Errorf("planet 0 at %v, %v, want 1140.5659105749555, 542.649314992455", b.X, b.Y)
+	}
+	if x := w.Bodies[149].X; x != 641.2029637510509 {
+		t.Errorf("planet 149 at x %v, want 641.2029637510509", x)
+	}
+	// The cursor is disabled by default
+	if w.Bounces != 0 || w.Steps != 600 || len(w.Bodies) != 150 {
+		t.Errorf("got %d bounces, %d steps, %d planets", w.Bounces, w.Steps, len(w.Bodies))
+	}
+}
+
+func TestNewWorld(t *testing.T) {
+	config := DefaultGravityConfig()
+	w := NewWorld(config, 1000, 600, 7)
+	if w.Sun.X != 500 || w.Sun.Y != 300 || w.Sun.Mass != config.Sun.Mass {
+		t.Errorf("sun at %v, %v with mass %v", w.Sun.X, w.Sun.Y, w.Sun.Mass)
+	}
+	for i, b := range w.Bodies {
+		r := math.Hypot(b.X-w.Sun.X, b.Y-w.Sun.Y)
+		if r < config.Planets.MinDistance || r > config.Planets.MaxDistance {
+			t.Errorf("planet %d at distance %v", i, r)
+		}
+		if b.Radius < config.Planets.MinRadius || b.Radius > config.Planets.MaxRadius {
+			t.Errorf("planet %d has radius %v", i, b.Radius)
+		}
+		// Circular orbits move at right angles to the sun
+		if dot := (b.X-w.Sun.X)*b.VX + (b.Y-w.Sun.Y)*b.VY; math.Abs(dot) > 1e-9*r {
+			t.Errorf("planet %d not in a circular orbit", i)
+		}
+	}
+
+	same := NewWorld(config, 1000, 600, 7)
+	other := NewWorld(config, 1000, 600, 8)
+	if same.Bodies[3] != w.Bodies[3] {
+		t.Error("same seed placed planets differently")
+	}
+	if other.Bodies[3] == w.Bodies[3] {
+		t.Error("different seeds placed planets the same")
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	w := NewWorld(DefaultGravityConfig(), 1280, 800, 1)
+	steps := 0
+	for i := 0; i < 10; i++ {
+		steps += w.Advance(0.75)
+	}
+	if steps != 7 || w.Steps != 7 {
+		t.Errorf("took %d steps for 7.5 frames, want 7", steps)
+	}
+	if got := w.Advance(0.5); got != 1 {
+		t.Errorf("leftover half frame not carried over: took %d steps", got)
+	}
+}
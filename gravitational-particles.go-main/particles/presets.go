@@ -0,0 +1,155 @@
+package particles
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// customPresets holds presets registered at runtime, e.g. from a preset
+// directory. They take precedence over the built-in presets.
+var customPresets = struct {
+	sync.RWMutex
+	configs map[string]*Config
+}{configs: make(map[string]*Config)}
+
+// RegisterPreset makes a configuration available as a named preset. Its
+// gravity warm-up, if any, runs once here. Configurations that can't be
+// copied, which GetPreset would have to do, are refused.
+func RegisterPreset(name string, config *Config) error {
+	config, err := config.WarmUp().Clone()
+	if err != nil {
+		return fmt.Errorf("invalid preset %q: %v", name, err)
+	}
+	customPresets.Lock()
+	defer customPresets.Unlock()
+	customPresets.configs[name] = config
+	return nil
+}
+
+// UnregisterPreset removes a registered preset and reports whether it
+// existed. A built-in preset of the same name becomes visible again.
+func UnregisterPreset(name string) bool {
+	customPresets.Lock()
+	defer customPresets.Unlock()
+	_, ok := customPresets.configs[name]
+	delete(customPresets.configs, name)
+	return ok
+}
+
+// customPreset returns a copy of a registered preset. RegisterPreset made
+// sure it can be copied.
+func customPreset(name string) (*Config, bool) {
+	customPresets.RLock()
+	config, ok := customPresets.configs[name]
+	customPresets.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	clone, err := config.Clone()
+	if err != nil {
+		return nil, false
+	}
+	return clone, true
+}
+
+// customPresetNames returns the names of registered presets, sorted
+func customPresetNames() []string {
+	customPresets.RLock()
+	defer customPresets.RUnlock()
+
+	names := make([]string, 0, len(customPresets.configs))
+	for name := range customPresets.configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadPresetDir registers every <name>.json file in dir as a preset named
+// after the file and returns the loaded names. Each file must hold a valid
+// particles.js configuration.
+func LoadPresetDir(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing presets: %v", err)
+	}
+
+	configs := make(map[string]*Config, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading preset: %v", err)
+		}
+
+		config, err := DecodeConfig(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid preset %s: %v", path, err)
+		}
+
+		configs[strings.TrimSuffix(filepath.Base(path), ".json")] = config
+	}
+
+	names := make([]string, 0, len(configs))
+	for name, config := range configs {
+		if err := RegisterPreset(name, config); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// SavePreset writes a preset to dir as <name>.json, the layout read by
+// LoadPresetDir, replacing the file atomically
+func SavePreset(dir, name string, config *Config) error {
+	if !ValidConfigID(name) {
+		return fmt.Errorf("invalid preset name %q", name)
+	}
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling preset to JSON: %v", err)
+	}
+
+	path := filepath.Join(dir, name+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("error writing preset %q: %v", name, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("error writing preset %q: %v", name, err)
+	}
+	return nil
+}
+
+// RemovePreset deletes the <name>.json file of a preset from dir
+func RemovePreset(dir, name string) error {
+	if !ValidConfigID(name) {
+		return fmt.Errorf("invalid preset name %q", name)
+	}
+	err := os.Remove(filepath.Join(dir, name+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error deleting preset %q: %v", name, err)
+	}
+	return nil
+}
+
+// Clone returns a deep copy of the configuration. It fails for values JSON
+// can't hold, such as NaN.
+func (c *Config) Clone() (*Config, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("error copying config: %v", err)
+	}
+	clone := &Config{}
+	if err := json.Unmarshal(data, clone); err != nil {
+		return nil, fmt.Errorf("error copying config: %v", err)
+	}
+	return clone, nil
+}
@@ -0,0 +1,228 @@
+package particles
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limiter decides whether the client identified by key may proceed
+type Limiter interface {
+	Allow(key string) bool
+}
+
+// sweepInterval is how often limiters drop state for idle clients
+const sweepInterval = time.Minute
+
+// TokenBucketLimiter allows each key a sustained Rate of events per second
+// with bursts of up to Burst events
+type TokenBucketLimiter struct {
+	Rate  float64
+	Burst int
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucketLimiter creates a limiter allowing rate events per second
+// per key, with bursts of up to burst events
+func NewTokenBucketLimiter(rate float64, burst int) *TokenBucketLimiter {
+	return &TokenBucketLimiter{
+		Rate:    rate,
+		Burst:   burst,
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from key's bucket if one is available
+func (l *TokenBucketLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(l.Burst), last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(l.Burst), b.tokens+now.Sub(b.last).Seconds()*l.Rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// RetryAfter returns how long key has to wait for its next token
+func (l *TokenBucketLimiter) RetryAfter(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok || b.tokens >= 1 || l.Rate <= 0 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / l.Rate * float64(time.Second))
+}
+
+// sweep drops buckets that have refilled completely, as they are
+// indistinguishable from new ones
+func (l *TokenBucketLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.Rate >= float64(l.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// WindowLimiter allows each key at most Limit events per fixed Window
+type WindowLimiter struct {
+	Limit  int
+	Window time.Duration
+
+	mu      sync.Mutex
+	windows map[string]*window
+	now     func() time.Time
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+// NewWindowLimiter creates a limiter allowing limit events per key in
+// every window
+func NewWindowLimiter(limit int, every time.Duration) *WindowLimiter {
+	return &WindowLimiter{
+		Limit:   limit,
+		Window:  every,
+		windows: make(map[string]*window),
+		now:     time.Now,
+	}
+}
+
+// Allow counts an event for key if its window has room left
+func (l *WindowLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.Window {
+		// Expired windows of other keys go with the first new window
+		if !ok && len(l.windows) > 0 {
+			for k, other := range l.windows {
+				if now.Sub(other.start) >= l.Window {
+					delete(l.windows, k)
+				}
+			}
+		}
+		w = &window{start: now}
+		l.windows[key] = w
+	}
+
+	if w.count >= l.Limit {
+		return false
+	}
+	w.count++
+	return true
+}
+
+// IPAllowList is a set of IP networks
+type IPAllowList struct {
+	nets []*net.IPNet
+}
+
+// ParseIPAllowList parses a comma separated list of IP addresses and CIDR
+// networks, e.g. "127.0.0.1,10.0.0.0/8,::1"
+func ParseIPAllowList(list string) (*IPAllowList, error) {
+	allow := &IPAllowList{}
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allow-list entry %q", entry)
+		}
+		allow.nets = append(allow.nets, ipNet)
+	}
+	return allow, nil
+}
+
+// Contains reports whether ip is in one of the list's networks
+func (a *IPAllowList) Contains(ip string) bool {
+	if a == nil {
+		return false
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range a.nets {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExemptLimiter applies Limiter to every key except the IPs in Exempt
+type ExemptLimiter struct {
+	Limiter Limiter
+	Exempt  *IPAllowList
+}
+
+// Allow lets exempt IPs through and asks the wrapped limiter otherwise
+func (l ExemptLimiter) Allow(key string) bool {
+	return l.Exempt.Contains(key) || l.Limiter.Allow(key)
+}
+
+// ClientIP returns the IP address of the client that sent r. With
+// trustForwarded it honors the X-Forwarded-For header set by a reverse
+// proxy; only enable that when the server is reachable through the proxy
+// alone, as clients can set the header themselves.
+func ClientIP(r *http.Request, trustForwarded bool) string {
+	if trustForwarded {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first := strings.TrimSpace(strings.Split(forwarded, ",")[0])
+			if net.ParseIP(first) != nil {
+				return first
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,172 @@
+package particles
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeClock is a time source tests advance by hand
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// allowed counts how many of n events l lets through for key
+func allowed(l Limiter, key string, n int) int {
+	count := 0
+	for i := 0; i < n; i++ {
+		if l.Allow(key) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestTokenBucketLimiter(t *testing.T) {
+	clock := newFakeClock()
+	l := NewTokenBucketLimiter(2, 5)
+	l.now = clock.now
+
+	if got := allowed(l, "a", 10); got != 5 {
+		t.Errorf("burst: allowed %d, want 5", got)
+	}
+	if got := l.RetryAfter("a"); got != 500*time.Millisecond {
+		t.Errorf("got retry after %v, want 500ms", got)
+	}
+	if got := allowed(l, "b", 1); got != 1 {
+		t.Error("keys share a bucket")
+	}
+
+	clock.advance(time.Second)
+	if got := allowed(l, "a", 10); got != 2 {
+		t.Errorf("after a second: allowed %d, want the rate, 2", got)
+	}
+	clock.advance(time.Hour)
+	if got := allowed(l, "a", 10); got != 5 {
+		t.Errorf("after an hour: allowed %d, want the burst, 5", got)
+	}
+	if got := l.RetryAfter("unknown"); got != 0 {
+		t.Errorf("unknown key: got retry after %v", got)
+	}
+}
+
+func TestTokenBucketLimiterSweep(t *testing.T) {
+	clock := newFakeClock()
+	l := NewTokenBucketLimiter(0.1, 2)
+	l.now = clock.now
+
+	l.Allow("idle")
+	l.Allow("busy")
+	clock.advance(sweepInterval - 10*time.Second)
+	allowed(l, "busy", 2)
+	clock.advance(10 * time.Second)
+	l.Allow("new")
+
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("refilled bucket kept")
+	}
+	if _, ok := l.buckets["busy"]; !ok {
+		t.Error("bucket still refilling dropped")
+	}
+}
+
+func TestWindowLimiter(t *testing.T) {
+	clock := newFakeClock()
+	l := NewWindowLimiter(3, time.Minute)
+	l.now = clock.now
+
+	if got := allowed(l, "a", 5); got != 3 {
+		t.Errorf("allowed %d, want 3", got)
+	}
+	clock.advance(59 * time.Second)
+	if l.Allow("a") {
+		t.Error("allowed before the window ended")
+	}
+	if !l.Allow("b") {
+		t.Error("keys share a window")
+	}
+	clock.advance(time.Second)
+	if got := allowed(l, "a", 5); got != 3 {
+		t.Errorf("next window: allowed %d, want 3", got)
+	}
+
+	clock.advance(2 * time.Minute)
+	l.Allow("c")
+	if _, ok := l.windows["b"]; ok {
+		t.Error("expired window kept")
+	}
+}
+
+func TestExemptLimiter(t *testing.T) {
+	exempt, err := ParseIPAllowList("10.0.0.0/8, ::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := ExemptLimiter{Limiter: NewWindowLimiter(1, time.Hour), Exempt: exempt}
+	if got := allowed(l, "10.1.2.3", 5); got != 5 {
+		t.Errorf("exempt IP: allowed %d, want 5", got)
+	}
+	if got := allowed(l, "::1", 5); got != 5 {
+		t.Errorf("exempt IPv6: allowed %d, want 5", got)
+	}
+	if got := allowed(l, "192.0.2.1", 5); got != 1 {
+		t.Errorf("limited IP: allowed %d, want 1", got)
+	}
+}
+
+func TestParseIPAllowList(t *testing.T) {
+	allow, err := ParseIPAllowList("127.0.0.1,192.168.0.0/16,,2001:db8::/32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ip, want := range map[string]bool{
+		"127.0.0.1":   true,
+		"127.0.0.2":   false,
+		"192.168.4.4": true,
+		"2001:db8::1": true,
+		"2001:db9::1": false,
+		"not an ip":   false,
+	} {
+		if got := allow.Contains(ip); got != want {
+			t.Errorf("Contains(%q) = %v, want %v", ip, got, want)
+		}
+	}
+
+	if _, err := ParseIPAllowList("10.0.0.0/33"); err == nil {
+		t.Error("invalid network accepted")
+	}
+	var none *IPAllowList
+	if none.Contains("127.0.0.1") {
+		t.Error("nil list contains an IP")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		remote    string
+		forwarded string
+		trust     bool
+		want      string
+	}{
+		{"192.0.2.1:1234", "", false, "192.0.2.1"},
+		{"192.0.2.1:1234", "203.0.113.7", false, "192.0.2.1"},
+		{"192.0.2.1:1234", "203.0.113.7, 10.0.0.1", true, "203.0.113.7"},
+		{"192.0.2.1:1234", "garbage", true, "192.0.2.1"},
+		{"[2001:db8::1]:443", "", true, "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tt.remote
+		if tt.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		if got := ClientIP(r, tt.trust); got != tt.want {
+			t.Errorf("%s %q trust=%v: got %s, want %s", tt.remote, tt.forwarded, tt.trust, got, tt.want)
+		}
+	}
+}
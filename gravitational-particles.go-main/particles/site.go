@@ -0,0 +1,186 @@
+package particles
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Reduced motion policies applied when a visitor prefers reduced motion
+const (
+	ReducedMotionRespect = "respect" // Render particles without movement
+	ReducedMotionDisable = "disable" // Don't render particles at all
+	ReducedMotionIgnore  = "ignore"  // Always animate
+)
+
+// SiteParams represents the [params.particles] section of a Hugo site
+// configuration. Its values are defaults that per-shortcode parameters
+// override.
+type SiteParams struct {
+	DefaultPreset string   `json:"default_preset"`
+	Palette       []string `json:"palette"`
+	JsPath        string   `json:"js_path"`
+	Endpoint      string   `json:"endpoint"`
+	ReducedMotion string   `json:"reduced_motion"`
+}
+
+// DefaultSiteParams returns the site parameters used when a site sets none
+func DefaultSiteParams() SiteParams {
+	return SiteParams{
+		DefaultPreset: PresetDefault,
+		ReducedMotion: ReducedMotionRespect,
+	}
+}
+
+// LoadSiteParams reads the [params.particles] section of a Hugo TOML
+// configuration file. Missing settings keep their default values.
+func LoadSiteParams(path string) (SiteParams, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SiteParams{}, fmt.Errorf("error reading site config: %v", err)
+	}
+
+	site, err := ParseSiteParams(data)
+	if err != nil {
+		return SiteParams{}, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	return site, nil
+}
+
+// ParseSiteParams parses the [params.particles] section of a Hugo TOML
+// configuration. Keys are matched case-insensitively, as Hugo does.
+func ParseSiteParams(data []byte) (SiteParams, error) {
+	site := DefaultSiteParams()
+
+	tree, err := parseTOML(data)
+	if err != nil {
+		return site, err
+	}
+
+	params, _ := lookupTable(tree, "params")
+	section, ok := lookupTable(params, "particles")
+	if !ok {
+		return site, nil
+	}
+
+	for key, value := range section {
+		switch strings.ToLower(key) {
+		case "default_preset":
+			s, err := stringParam(key, value)
+			if err != nil {
+				return site, err
+			}
+			site.DefaultPreset = s
+		case "palette":
+			list, ok := value.([]interface{})
+			if !ok {
+				return site, fmt.Errorf("params.particles.%s: expected an array of colors", key)
+			}
+			site.Palette = nil
+			for _, item := range list {
+				s, err := stringParam(key, item)
+				if err != nil {
+					return site, err
+				}
+				site.Palette = append(site.Palette, s)
+			}
+		case "js_path":
+			s, err := stringParam(key, value)
+			if err != nil {
+				return site, err
+			}
+			site.JsPath = s
+		case "endpoint":
+			s, err := stringParam(key, value)
+			if err != nil {
+				return site, err
+			}
+			site.Endpoint = s
+		case "reduced_motion":
+			s, err := stringParam(key, value)
+			if err != nil {
+				return site, err
+			}
+			site.ReducedMotion = s
+		}
+	}
+
+	return site, site.Validate()
+}
+
+// Validate checks the site parameters for unknown presets and policies
+func (s SiteParams) Validate() error {
+	if s.DefaultPreset != "" && !IsPreset(s.DefaultPreset) {
+		return fmt.Errorf("params.particles.default_preset: unknown preset %q", s.DefaultPreset)
+	}
+
+	switch s.ReducedMotion {
+	case "", ReducedMotionRespect, ReducedMotionDisable, ReducedMotionIgnore:
+	default:
+		return fmt.Errorf("params.particles.reduced_motion: unknown policy %q", s.ReducedMotion)
+	}
+
+	return nil
+}
+
+// MergeParams returns the shortcode parameters with site defaults filled in
+// for every parameter the shortcode did not set
+func (s SiteParams) MergeParams(params map[string]string) map[string]string {
+	merged := make(map[string]string, len(params)+4)
+	if s.DefaultPreset != "" {
+		merged["preset"] = s.DefaultPreset
+	}
+	if len(s.Palette) > 0 {
+		merged["color"] = strings.Join(s.Palette, ",")
+	}
+	if s.JsPath != "" {
+		merged["js-path"] = s.JsPath
+	}
+	if s.Endpoint != "" {
+		merged["config-url"] = s.Endpoint
+	}
+	if s.ReducedMotion != "" {
+		merged["reduced-motion"] = s.ReducedMotion
+	}
+
+	for k, v := range params {
+		if v != "" {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// ColorValue converts a color parameter to a Color value. A comma separated
+// list becomes a palette particles.js picks from at random.
+func ColorValue(param string) interface{} {
+	if !strings.Contains(param, ",") {
+		return param
+	}
+
+	var palette []string
+	for _, c := range strings.Split(param, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			palette = append(palette, c)
+		}
+	}
+	return palette
+}
+
+func lookupTable(tree map[string]interface{}, name string) (map[string]interface{}, bool) {
+	for key, value := range tree {
+		if strings.EqualFold(key, name) {
+			table, ok := value.(map[string]interface{})
+			return table, ok
+		}
+	}
+	return nil, false
+}
+
+func stringParam(key string, value interface{}) (string, error) {
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("params.particles.%s: expected a string, got %v", key, value)
+	}
+	return s, nil
+}
@@ -0,0 +1,279 @@
+package particles
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ConfigStore keeps the configurations served by HugoHandler
+type ConfigStore interface {
+	// Get returns the configuration stored under id, if any
+	Get(id string) (*Config, bool, error)
+	// Put stores a configuration under id, replacing any existing one
+	Put(id string, config *Config) error
+}
+
+// Pinger is implemented by stores that can report whether their backend
+// is reachable
+type Pinger interface {
+	Ping() error
+}
+
+// Deleter is implemented by stores that can remove configurations
+type Deleter interface {
+	// Delete removes the configuration stored under id and reports
+	// whether there was one
+	Delete(id string) (bool, error)
+}
+
+// StoreStats counts store lookups and evictions
+type StoreStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// StatsReporter is implemented by stores that count their lookups
+type StatsReporter interface {
+	Stats() StoreStats
+}
+
+// MemoryStore is a ConfigStore kept in process memory. With a capacity it
+// evicts the least recently used configuration when full.
+type MemoryStore struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // Front is the most recently used
+	stats    StoreStats
+}
+
+type memoryEntry struct {
+	id     string
+	config *Config
+}
+
+// NewMemoryStore creates an empty, unbounded in-memory store
+func NewMemoryStore() *MemoryStore {
+	return NewBoundedMemoryStore(0)
+}
+
+// NewBoundedMemoryStore creates an in-memory store holding at most capacity
+// configurations; 0 means unbounded
+func NewBoundedMemoryStore(capacity int) *MemoryStore {
+	return &MemoryStore{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the configuration stored under id
+func (s *MemoryStore) Get(id string) (*Config, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[id]
+	if !ok {
+		s.stats.Misses++
+		return nil, false, nil
+	}
+	s.stats.Hits++
+	s.order.MoveToFront(elem)
+	return elem.Value.(*memoryEntry).config, true, nil
+}
+
+// Put stores a configuration under id
+func (s *MemoryStore) Put(id string, config *Config) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[id]; ok {
+		elem.Value.(*memoryEntry).config = config
+		s.order.MoveToFront(elem)
+		return nil
+	}
+
+	s.entries[id] = s.order.PushFront(&memoryEntry{id: id, config: config})
+	if s.capacity > 0 && s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*memoryEntry).id)
+		s.stats.Evictions++
+	}
+	return nil
+}
+
+// Delete removes the configuration stored under id
+func (s *MemoryStore) Delete(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[id]
+	if !ok {
+		return false, nil
+	}
+	s.order.Remove(elem)
+	delete(s.entries, id)
+	return true, nil
+}
+
+// Stats returns the store's lookup and eviction counts
+func (s *MemoryStore) Stats() StoreStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// configIDPattern restricts IDs to names that are safe as file names
+var configIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9_.-]{0,127}$`)
+
+// ValidConfigID reports whether id can be used as a configuration ID
+func ValidConfigID(id string) bool {
+	return configIDPattern.MatchString(id)
+}
+
+// FileStore is a ConfigStore keeping one JSON file per configuration, so
+// configurations survive restarts
+type FileStore struct {
+	Dir    string
+	mu     sync.Mutex
+	hits   uint64
+	misses uint64
+}
+
+// NewFileStore creates a file store in dir, creating the directory
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating store directory: %v", err)
+	}
+	return &FileStore{Dir: dir}, nil
+}
+
+func (s *FileStore) path(id string) (string, error) {
+	if !ValidConfigID(id) {
+		return "", fmt.Errorf("invalid config ID %q", id)
+	}
+	return filepath.Join(s.Dir, id+".json"), nil
+}
+
+// Get reads the configuration stored under id
+func (s *FileStore) Get(id string) (*Config, bool, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		atomic.AddUint64(&s.misses, 1)
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading config %q: %v", id, err)
+	}
+
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, false, fmt.Errorf("error decoding config %q: %v", id, err)
+	}
+	atomic.AddUint64(&s.hits, 1)
+	return config, true, nil
+}
+
+// Stats returns the store's lookup counts. A file store never evicts.
+func (s *FileStore) Stats() StoreStats {
+	return StoreStats{
+		Hits:   atomic.LoadUint64(&s.hits),
+		Misses: atomic.LoadUint64(&s.misses),
+	}
+}
+
+// Ping checks that the store directory is still accessible
+func (s *FileStore) Ping() error {
+	info, err := os.Stat(s.Dir)
+	if err != nil {
+		return fmt.Errorf("store directory unavailable: %v", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("store path %s is not a directory", s.Dir)
+	}
+	return nil
+}
+
+// Put writes a configuration under id, replacing the file atomically
+func (s *FileStore) Put(id string, config *Config) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("error marshaling config to JSON: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("error writing config %q: %v", id, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("error writing config %q: %v", id, err)
+	}
+	return nil
+}
+
+// Delete removes the file of the configuration stored under id
+func (s *FileStore) Delete(id string) (bool, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error deleting config %q: %v", id, err)
+	}
+	return true, nil
+}
+
+// OpenStore opens a store from a backend specification: "memory",
+// "memory:<capacity>" for a bounded MemoryStore, or "file:<dir>" for a
+// FileStore in dir
+func OpenStore(spec string) (ConfigStore, error) {
+	switch {
+	case spec == "" || spec == "memory":
+		return NewMemoryStore(), nil
+	case strings.HasPrefix(spec, "memory:"):
+		capacity, err := strconv.Atoi(strings.TrimPrefix(spec, "memory:"))
+		if err != nil || capacity < 1 {
+			return nil, fmt.Errorf("store %q: capacity must be a positive number", spec)
+		}
+		return NewBoundedMemoryStore(capacity), nil
+	case strings.HasPrefix(spec, "file:"):
+		dir := strings.TrimPrefix(spec, "file:")
+		if dir == "" {
+			return nil, fmt.Errorf("store %q: missing directory", spec)
+		}
+		return NewFileStore(dir)
+	default:
+		return nil, fmt.Errorf("unknown store backend %q", spec)
+	}
+}
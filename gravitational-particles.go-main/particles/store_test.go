@@ -0,0 +1,160 @@
+package particles
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/yourusername/particles-go/particles/physics"
+)
+
+// testStore runs the behaviour every ConfigStore shares
+func testStore(t *testing.T, store ConfigStore) {
+	t.Helper()
+	if _, ok, err := store.Get("missing"); ok || err != nil {
+		t.Fatalf("Get of a missing config: %v, %v", ok, err)
+	}
+
+	config := GetPreset(PresetSnow)
+	gravity := physics.DefaultGravityConfig()
+	gravity.State = &physics.State{Step: 3, Bodies: []physics.Body{{ID: 1, X: 2, Y: 3, Radius: 4, Mass: 1}}}
+	config.Gravity = &gravity
+	if err := store.Put("snow-1", config); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := store.Get("snow-1")
+	if !ok || err != nil {
+		t.Fatalf("Get after Put: %v, %v", ok, err)
+	}
+	if !reflect.DeepEqual(got, config) {
+		t.Errorf("got %+v, want %+v", got, config)
+	}
+
+	if err := store.Put("snow-1", DefaultConfig()); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, _ := store.Get("snow-1"); got.Particles.Number.Value != DefaultConfig().Particles.Number.Value {
+		t.Error("Put didn't replace the config")
+	}
+
+	deleter := store.(Deleter)
+	if deleted, err := deleter.Delete("snow-1"); !deleted || err != nil {
+		t.Errorf("Delete: %v, %v", deleted, err)
+	}
+	if deleted, err := deleter.Delete("snow-1"); deleted || err != nil {
+		t.Errorf("second Delete: %v, %v", deleted, err)
+	}
+	if _, ok, _ := store.Get("snow-1"); ok {
+		t.Error("deleted config still stored")
+	}
+
+	stats := store.(StatsReporter).Stats()
+	if stats.Hits != 2 || stats.Misses != 2 {
+		t.Errorf("got %d hits and %d misses, want 2 and 2", stats.Hits, stats.Misses)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "configs")
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, store)
+
+	if err := store.Ping(); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+	if err := store.Put("../escape", DefaultConfig()); err == nil {
+		t.Error("stored a config outside the directory")
+	}
+	if _, _, err := store.Get("../escape"); err == nil {
+		t.Error("read a config outside the directory")
+	}
+
+	os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o644)
+	if _, _, err := store.Get("broken"); err == nil {
+		t.Error("decoded a broken file")
+	}
+
+	// Configs survive reopening the store
+	store.Put("kept", DefaultConfig())
+	reopened, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := reopened.Get("kept"); !ok {
+		t.Error("config lost on reopening")
+	}
+
+	os.RemoveAll(dir)
+	if err := store.Ping(); err == nil {
+		t.Error("Ping succeeded without a directory")
+	}
+}
+
+func TestBoundedMemoryStore(t *testing.T) {
+	store := NewBoundedMemoryStore(2)
+	store.Put("a", DefaultConfig())
+	store.Put("b", DefaultConfig())
+	store.Get("a") // b is now the least recently used
+	store.Put("c", DefaultConfig())
+
+	for id, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok, _ := store.Get(id); ok != want {
+			t.Errorf("%s stored: %v, want %v", id, ok, want)
+		}
+	}
+	if evictions := store.Stats().Evictions; evictions != 1 {
+		t.Errorf("got %d evictions, want 1", evictions)
+	}
+}
+
+func TestValidConfigID(t *testing.T) {
+	for id, want := range map[string]bool{
+		"config-1":   true,
+		"a.b_c":      true,
+		"":           false,
+		".hidden":    false,
+		"../etc":     false,
+		"a/b":        false,
+		"with space": false,
+	} {
+		if got := ValidConfigID(id); got != want {
+			t.Errorf("%q: got %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestOpenStore(t *testing.T) {
+	tests := []struct {
+		spec string
+		want interface{}
+	}{
+		{"", &MemoryStore{}},
+		{"memory", &MemoryStore{}},
+		{"memory:10", &MemoryStore{}},
+		{"file:" + t.TempDir(), &FileStore{}},
+		{"memory:0", nil},
+		{"memory:x", nil},
+		{"file:", nil},
+		{"redis://localhost", nil},
+	}
+	for _, tt := range tests {
+		store, err := OpenStore(tt.spec)
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("%q: opened %T", tt.spec, store)
+			}
+			continue
+		}
+		if err != nil || reflect.TypeOf(store) != reflect.TypeOf(tt.want) {
+			t.Errorf("%q: got %T, %v", tt.spec, store, err)
+		}
+	}
+}
@@ -0,0 +1,521 @@
+package particles
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseTOML decodes the subset of TOML used by Hugo site configurations:
+// tables, arrays of tables, dotted keys, strings, numbers, booleans,
+// dates and times, arrays and inline tables. Dates and times are kept as
+// the strings they were written as.
+func parseTOML(data []byte) (map[string]interface{}, error) {
+	p := &tomlParser{src: string(data), line: 1}
+	root := make(map[string]interface{})
+	current := root
+
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return root, nil
+		}
+
+		if p.peek() == '[' {
+			table, err := p.parseHeader(root)
+			if err != nil {
+				return nil, err
+			}
+			current = table
+			continue
+		}
+
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.consume('=') {
+			return nil, p.errorf("expected '=' after key %q", strings.Join(key, "."))
+		}
+		p.skipSpace()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.assign(current, key, value); err != nil {
+			return nil, err
+		}
+		if err := p.endOfLine(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+type tomlParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("toml: line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *tomlParser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *tomlParser) consume(c byte) bool {
+	if p.peek() == c && !p.eof() {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// skipSpace skips spaces and tabs on the current line
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r':
+			p.pos++
+		case '\n':
+			p.pos++
+			p.line++
+		case '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) endOfLine() error {
+	p.skipSpace()
+	if p.peek() == '#' {
+		for !p.eof() && p.peek() != '\n' {
+			p.pos++
+		}
+	}
+	p.consume('\r')
+	if p.eof() {
+		return nil
+	}
+	if !p.consume('\n') {
+		return p.errorf("unexpected %q after value", p.peek())
+	}
+	p.line++
+	return nil
+}
+
+// parseHeader parses a [table] or [[array.of.tables]] header and returns
+// the table subsequent keys are assigned to
+func (p *tomlParser) parseHeader(root map[string]interface{}) (map[string]interface{}, error) {
+	p.pos++
+	array := p.consume('[')
+	p.skipSpace()
+	key, err := p.parseKey()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.consume(']') || (array && !p.consume(']')) {
+		return nil, p.errorf("unterminated table header")
+	}
+	if err := p.endOfLine(); err != nil {
+		return nil, err
+	}
+
+	table := root
+	for i, part := range key {
+		last := i == len(key)-1
+		switch existing := table[part].(type) {
+		case nil:
+			if last && array {
+				next := make(map[string]interface{})
+				table[part] = []interface{}{next}
+				return next, nil
+			}
+			next := make(map[string]interface{})
+			table[part] = next
+			table = next
+		case map[string]interface{}:
+			if last && array {
+				return nil, p.errorf("key %q is already a table", strings.Join(key, "."))
+			}
+			table = existing
+		case []interface{}:
+			if last && array {
+				next := make(map[string]interface{})
+				table[part] = append(existing, next)
+				return next, nil
+			}
+			next, ok := existing[len(existing)-1].(map[string]interface{})
+			if !ok {
+				return nil, p.errorf("key %q is not a table", strings.Join(key[:i+1], "."))
+			}
+			table = next
+		default:
+			return nil, p.errorf("key %q is not a table", strings.Join(key[:i+1], "."))
+		}
+	}
+	return table, nil
+}
+
+// parseKey parses a possibly dotted, possibly quoted key
+func (p *tomlParser) parseKey() ([]string, error) {
+	var parts []string
+	for {
+		p.skipSpace()
+		var part string
+		switch p.peek() {
+		case '"':
+			s, err := p.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			part = s
+		case '\'':
+			s, err := p.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			part = s
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, p.errorf("expected key, found %q", p.peek())
+			}
+			part = p.src[start:p.pos]
+		}
+		parts = append(parts, part)
+
+		p.skipSpace()
+		if !p.consume('.') {
+			return parts, nil
+		}
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *tomlParser) assign(table map[string]interface{}, key []string, value interface{}) error {
+	for _, part := range key[:len(key)-1] {
+		switch existing := table[part].(type) {
+		case nil:
+			next := make(map[string]interface{})
+			table[part] = next
+			table = next
+		case map[string]interface{}:
+			table = existing
+		default:
+			return p.errorf("key %q is not a table", part)
+		}
+	}
+
+	name := key[len(key)-1]
+	if _, exists := table[name]; exists {
+		return p.errorf("duplicate key %q", strings.Join(key, "."))
+	}
+	table[name] = value
+	return nil
+}
+
+func (p *tomlParser) parseValue() (interface{}, error) {
+	switch c := p.peek(); {
+	case c == '"':
+		if strings.HasPrefix(p.src[p.pos:], `"""`) {
+			return p.parseMultilineString(`"""`)
+		}
+		return p.parseBasicString()
+	case c == '\'':
+		if strings.HasPrefix(p.src[p.pos:], "'''") {
+			return p.parseMultilineString("'''")
+		}
+		return p.parseLiteralString()
+	case c == '[':
+		return p.parseArray()
+	case c == '{':
+		return p.parseInlineTable()
+	default:
+		return p.parseScalar()
+	}
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.src[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+// parseMultilineString parses a basic or literal string quoted with three
+// double or single quotes, which may span lines. A line break right after
+// the opening quotes is dropped, and in basic strings a backslash at the
+// end of a line joins it to the next without the whitespace in between.
+func (p *tomlParser) parseMultilineString(quotes string) (string, error) {
+	p.pos += len(quotes)
+	if strings.HasPrefix(p.src[p.pos:], "\r\n") {
+		p.pos++
+	}
+	if p.consume('\n') {
+		p.line++
+	}
+
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated multi-line string")
+		}
+		if strings.HasPrefix(p.src[p.pos:], quotes) {
+			// Up to two quotes may end the string's content
+			end := p.pos + len(quotes)
+			for i := 0; i < 2 && end < len(p.src) && p.src[end] == quotes[0]; i++ {
+				end++
+			}
+			b.WriteString(p.src[p.pos : end-len(quotes)])
+			p.pos = end
+			return b.String(), nil
+		}
+
+		c := p.src[p.pos]
+		p.pos++
+		switch {
+		case c == '\n':
+			p.line++
+			b.WriteByte(c)
+		case c == '\\' && quotes == `"""`:
+			if rest := strings.TrimLeft(p.src[p.pos:], " \t\r"); strings.HasPrefix(rest, "\n") {
+				// Line ending backslash
+				for !p.eof() && strings.ContainsRune(" \t\r\n", rune(p.peek())) {
+					if p.peek() == '\n' {
+						p.line++
+					}
+					p.pos++
+				}
+				continue
+			}
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+// parseEscape decodes the escape sequence following a backslash
+func (p *tomlParser) parseEscape(b *strings.Builder) error {
+	if p.eof() {
+		return p.errorf("unterminated string")
+	}
+	esc := p.src[p.pos]
+	p.pos++
+	switch esc {
+	case 'n':
+		b.WriteByte('\n')
+	case 't':
+		b.WriteByte('\t')
+	case 'r':
+		b.WriteByte('\r')
+	case '"', '\\':
+		b.WriteByte(esc)
+	case 'u', 'U':
+		n := 4
+		if esc == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.src) {
+			return p.errorf("invalid unicode escape")
+		}
+		r, err := strconv.ParseUint(p.src[p.pos:p.pos+n], 16, 32)
+		if err != nil {
+			return p.errorf("invalid unicode escape")
+		}
+		b.WriteRune(rune(r))
+		p.pos += n
+	default:
+		return p.errorf("invalid escape \\%c", esc)
+	}
+	return nil
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++
+	start := p.pos
+	for !p.eof() && p.peek() != '\'' && p.peek() != '\n' {
+		p.pos++
+	}
+	if !p.consume('\'') {
+		return "", p.errorf("unterminated string")
+	}
+	return p.src[start : p.pos-1], nil
+}
+
+func (p *tomlParser) parseArray() (interface{}, error) {
+	p.pos++
+	values := []interface{}{}
+	for {
+		p.skipBlank()
+		if p.consume(']') {
+			return values, nil
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		p.skipBlank()
+		if p.consume(']') {
+			return values, nil
+		}
+		if !p.consume(',') {
+			return nil, p.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (interface{}, error) {
+	p.pos++
+	table := make(map[string]interface{})
+	p.skipSpace()
+	if p.consume('}') {
+		return table, nil
+	}
+	for {
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.consume('=') {
+			return nil, p.errorf("expected '=' in inline table")
+		}
+		p.skipSpace()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.assign(table, key, value); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.consume('}') {
+			return table, nil
+		}
+		if !p.consume(',') {
+			return nil, p.errorf("expected ',' or '}' in inline table")
+		}
+		p.skipSpace()
+	}
+}
+
+func (p *tomlParser) parseScalar() (interface{}, error) {
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", rune(p.peek())) {
+		p.pos++
+	}
+	raw := p.src[start:p.pos]
+
+	switch raw {
+	case "":
+		return nil, p.errorf("expected value")
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+
+	if isTOMLDate(raw) {
+		// A space may separate the date from the time
+		if len(p.src) > p.pos+1 && p.peek() == ' ' && p.src[p.pos+1] >= '0' && p.src[p.pos+1] <= '9' {
+			end := p.pos + 1
+			for end < len(p.src) && !strings.ContainsRune(" \t\r\n,]}#", rune(p.src[end])) {
+				end++
+			}
+			raw += "T" + p.src[p.pos+1:end]
+			p.pos = end
+		}
+		if !validTOMLDateTime(raw) {
+			return nil, p.errorf("invalid date %q", raw)
+		}
+		return raw, nil
+	}
+	if len(raw) > 2 && raw[2] == ':' {
+		if _, err := time.Parse("15:04:05.999999999", raw); err != nil {
+			return nil, p.errorf("invalid time %q", raw)
+		}
+		return raw, nil
+	}
+
+	clean := strings.ReplaceAll(raw, "_", "")
+	if i, err := strconv.ParseInt(clean, 0, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(clean, 64); err == nil {
+		return f, nil
+	}
+	return nil, p.errorf("unsupported value %q", raw)
+}
+
+// isTOMLDate reports whether a value starts with a YYYY-MM-DD date
+func isTOMLDate(raw string) bool {
+	if len(raw) < 10 || raw[4] != '-' || raw[7] != '-' {
+		return false
+	}
+	for _, i := range []int{0, 1, 2, 3, 5, 6, 8, 9} {
+		if raw[i] < '0' || raw[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// validTOMLDateTime reports whether raw is a date, a local date-time or a
+// date-time with an offset
+func validTOMLDateTime(raw string) bool {
+	raw = strings.Replace(raw, "t", "T", 1)
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02"} {
+		if _, err := time.Parse(layout, strings.Replace(raw, "z", "Z", 1)); err == nil {
+			return true
+		}
+	}
+	return false
+}
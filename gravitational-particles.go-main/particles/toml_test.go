@@ -0,0 +1,146 @@
+package particles
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type tomlTable = map[string]interface{}
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		desc string
+		src  string
+		want tomlTable
+	}{
+		{"empty", "", tomlTable{}},
+		{"comments and blank lines", "# comment\n\n  # indented\n", tomlTable{}},
+		{"scalars", "s = \"a\"\ni = 42\nneg = -7\nhex = 0x1f\nf = 1.5\nexp = 1e3\nunder = 1_000\nyes = true\nno = false\n", tomlTable{
+			"s": "a", "i": int64(42), "neg": int64(-7), "hex": int64(31), "f": 1.5, "exp": 1000.0, "under": int64(1000), "yes": true, "no": false,
+		}},
+		{"trailing comment", "a = 1 # one\nb = \"#not a comment\"\n", tomlTable{"a": int64(1), "b": "#not a comment"}},
+		{"basic string escapes", `s = "tab\there \"quoted\" \\ \u00e9"` + "\n", tomlTable{"s": "tab\there \"quoted\" \\ é"}},
+		{"literal string", `s = 'C:\path\n'` + "\n", tomlTable{"s": `C:\path\n`}},
+		{"tables", "[params]\ndescription = \"d\"\n[params.particles]\n  default_preset = \"snow\"\n", tomlTable{
+			"params": tomlTable{"description": "d", "particles": tomlTable{"default_preset": "snow"}},
+		}},
+		{"dotted and quoted keys", "a.b = 1\n\"c.d\" = 2\n'e' = 3\n", tomlTable{
+			"a": tomlTable{"b": int64(1)}, "c.d": int64(2), "e": int64(3),
+		}},
+		{"arrays", "a = [1, 2, 3]\nb = [\"x\", 'y',]\nc = [\n  [1, 2],\n  [], # nested\n]\n", tomlTable{
+			"a": []interface{}{int64(1), int64(2), int64(3)},
+			"b": []interface{}{"x", "y"},
+			"c": []interface{}{[]interface{}{int64(1), int64(2)}, []interface{}{}},
+		}},
+		{"inline tables", "p = { x = 1, y = \"two\", z = { w = true } }\n", tomlTable{
+			"p": tomlTable{"x": int64(1), "y": "two", "z": tomlTable{"w": true}},
+		}},
+		{"arrays of tables", "[[menu.main]]\nname = \"a\"\n[[menu.main]]\nname = \"b\"\n[menu.main.params]\nx = 1\n", tomlTable{
+			"menu": tomlTable{"main": []interface{}{
+				tomlTable{"name": "a"},
+				tomlTable{"name": "b", "params": tomlTable{"x": int64(1)}},
+			}},
+		}},
+		{"CRLF line endings", "a = 1\r\n[t]\r\nb = 2\r\n", tomlTable{"a": int64(1), "t": tomlTable{"b": int64(2)}}},
+		{"dates and times", "a = 2023-05-15T10:00:00Z\nb = 2023-05-15 10:00:00.5+02:00\nc = 2023-05-15T10:00:00\nd = 2023-05-15\ne = 07:32:00 # comment\nf = [2023-05-15, 2024-01-01]\n", tomlTable{
+			"a": "2023-05-15T10:00:00Z", "b": "2023-05-15T10:00:00.5+02:00", "c": "2023-05-15T10:00:00", "d": "2023-05-15", "e": "07:32:00",
+			"f": []interface{}{"2023-05-15", "2024-01-01"},
+		}},
+		{"multi-line strings", "a = \"\"\"\nline one\nline \\\n    two \\t\"\"\"\"\nb = '''\nC:\\path\n'''\nc = 1\n", tomlTable{
+			"a": "line one\nline two \t\"", "b": "C:\\path\n", "c": int64(1),
+		}},
+	}
+	for _, tt := range tests {
+		got, err := parseTOML([]byte(tt.src))
+		if err != nil {
+			t.Errorf("%s: %v", tt.desc, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %#v, want %#v", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		desc string
+		src  string
+		err  string
+	}{
+		{"missing equals", "a 1\n", "line 1: expected '='"},
+		{"missing value", "a =\n", "line 1: expected value"},
+		{"unknown value", "a = nope\n", `unsupported value "nope"`},
+		{"invalid date", "a = 2023-13-15\n", `invalid date "2023-13-15"`},
+		{"invalid time", "a = 25:00:00\n", `invalid time "25:00:00"`},
+		{"unterminated multi-line string", "a = \"\"\"\ntext\n", "unterminated multi-line string"},
+		{"duplicate key", "a = 1\nb = 2\na = 3\n", `line 3: duplicate key "a"`},
+		{"key reused as table", "a = 1\n[a]\n", `key "a" is not a table`},
+		{"dotted key through a value", "a = 1\na.b = 2\n", `key "a" is not a table`},
+		{"table reused as array", "[a]\n[[a]]\n", `key "a" is already a table`},
+		{"unterminated header", "[params\n", "unterminated table header"},
+		{"unterminated string", "a = \"open\n", "line 1"},
+		{"unterminated array", "a = [1, 2\n", "line"},
+		{"trailing text", "a = 1 2\n", "line 1"},
+		{"empty key", "= 1\n", "expected key"},
+	}
+	for _, tt := range tests {
+		_, err := parseTOML([]byte(tt.src))
+		if err == nil {
+			t.Errorf("%s: no error", tt.desc)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), "toml: ") || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: got %q, want %q", tt.desc, err, tt.err)
+		}
+	}
+}
+
+func TestParseSiteParams(t *testing.T) {
+	tests := []struct {
+		desc string
+		src  string
+		want SiteParams
+		err  string
+	}{
+		{"no section", "title = \"x\"\n", DefaultSiteParams(), ""},
+		{"section", "[params.particles]\ndefault_preset = \"snow\"\npalette = [\"#ffffff\", \"#000000\"]\njs_path = \"/js/p.js\"\nreduced_motion = \"disable\"\n",
+			SiteParams{DefaultPreset: "snow", Palette: []string{"#ffffff", "#000000"}, JsPath: "/js/p.js", ReducedMotion: ReducedMotionDisable}, ""},
+		{"keys ignore case", "[Params.Particles]\nDefault_Preset = \"snow\"\n",
+			SiteParams{DefaultPreset: "snow", ReducedMotion: ReducedMotionRespect}, ""},
+		{"unknown preset", "[params.particles]\ndefault_preset = \"nope\"\n", SiteParams{}, "unknown preset"},
+		{"unknown policy", "[params.particles]\nreduced_motion = \"sometimes\"\n", SiteParams{}, "unknown policy"},
+		{"wrong type", "[params.particles]\njs_path = 3\n", SiteParams{}, "js_path"},
+		{"syntax error", "[params.particles\n", SiteParams{}, "toml: line 1"},
+	}
+	for _, tt := range tests {
+		got, err := ParseSiteParams([]byte(tt.src))
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: got error %v, want %q", tt.desc, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.desc, err)
+		} else if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestLoadSiteParamsDemo(t *testing.T) {
+	site, err := LoadSiteParams("../hugo-demo/config.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SiteParams{
+		DefaultPreset: PresetDefault,
+		JsPath:        "https://cdn.jsdelivr.net/npm/particles.js@2.0.0/particles.min.js",
+		ReducedMotion: ReducedMotionRespect,
+	}
+	if !reflect.DeepEqual(site, want) {
+		t.Errorf("got %+v, want %+v", site, want)
+	}
+}
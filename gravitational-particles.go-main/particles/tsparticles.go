@@ -0,0 +1,579 @@
+package particles
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/yourusername/particles-go/particles/physics"
+)
+
+// Configuration formats HugoHandler can serve
+const (
+	FormatParticlesJS = "particlesjs"
+	FormatTSParticles = "tsparticles"
+)
+
+// TSRange is a tsParticles numeric value that is either a single number or
+// a {min, max} range particles pick from at random
+type TSRange struct {
+	Min float64
+	Max float64
+}
+
+// NewTSValue returns a range holding a single value
+func NewTSValue(v float64) TSRange {
+	return TSRange{Min: v, Max: v}
+}
+
+// IsRange reports whether the range spans more than one value
+func (r TSRange) IsRange() bool {
+	return r.Min != r.Max
+}
+
+// MarshalJSON encodes single values as numbers and ranges as objects
+func (r TSRange) MarshalJSON() ([]byte, error) {
+	if !r.IsRange() {
+		return json.Marshal(r.Max)
+	}
+	return json.Marshal(struct {
+		Min float64 `json:"min"`
+		Max float64 `json:"max"`
+	}{r.Min, r.Max})
+}
+
+// UnmarshalJSON decodes a number or a {min, max} object
+func (r *TSRange) UnmarshalJSON(data []byte) error {
+	var v float64
+	if err := json.Unmarshal(data, &v); err == nil {
+		*r = NewTSValue(v)
+		return nil
+	}
+
+	var rng struct {
+		Min float64 `json:"min"`
+		Max float64 `json:"max"`
+	}
+	if err := json.Unmarshal(data, &rng); err != nil {
+		return fmt.Errorf("expected a number or a {min, max} range")
+	}
+	*r = TSRange{Min: rng.Min, Max: rng.Max}
+	return nil
+}
+
+// TSColor represents a tsParticles color
+type TSColor struct {
+	Value interface{} `json:"value"`
+}
+
+// TSDensity represents the tsParticles number density configuration
+type TSDensity struct {
+	Enable bool    `json:"enable"`
+	Area   float64 `json:"area"`
+}
+
+// TSNumber represents the tsParticles number configuration
+type TSNumber struct {
+	Value   int       `json:"value"`
+	Density TSDensity `json:"density"`
+}
+
+// TSPolygon represents the tsParticles polygon shape options
+type TSPolygon struct {
+	Sides int `json:"sides"`
+}
+
+// TSImage represents the tsParticles image shape options
+type TSImage struct {
+	Src    string  `json:"src"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// TSShapeOptions represents the per-shape tsParticles options
+type TSShapeOptions struct {
+	Polygon *TSPolygon `json:"polygon,omitempty"`
+	Image   *TSImage   `json:"image,omitempty"`
+}
+
+// TSShape represents the tsParticles shape configuration
+type TSShape struct {
+	Type    interface{}    `json:"type"`
+	Options TSShapeOptions `json:"options"`
+}
+
+// TSStroke represents the tsParticles stroke configuration
+type TSStroke struct {
+	Width float64 `json:"width"`
+	Color TSColor `json:"color"`
+}
+
+// TSAnimation represents a tsParticles value animation
+type TSAnimation struct {
+	Enable       bool    `json:"enable"`
+	Speed        float64 `json:"speed"`
+	MinimumValue float64 `json:"minimumValue"`
+	Sync         bool    `json:"sync"`
+}
+
+// TSAnimatedValue represents an animatable tsParticles value such as
+// opacity or size
+type TSAnimatedValue struct {
+	Value     TSRange     `json:"value"`
+	Animation TSAnimation `json:"animation"`
+}
+
+// TSLinks represents the tsParticles links configuration
+type TSLinks struct {
+	Enable   bool    `json:"enable"`
+	Distance float64 `json:"distance"`
+	Color    TSColor `json:"color"`
+	Opacity  float64 `json:"opacity"`
+	Width    float64 `json:"width"`
+}
+
+// TSOutModes represents the tsParticles out modes configuration
+type TSOutModes struct {
+	Default string `json:"default"`
+}
+
+// TSRotate represents the tsParticles attract rotation
+type TSRotate struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// TSAttract represents the tsParticles move attract configuration
+type TSAttract struct {
+	Enable bool     `json:"enable"`
+	Rotate TSRotate `json:"rotate"`
+}
+
+// TSMove represents the tsParticles move configuration
+type TSMove struct {
+	Enable    bool       `json:"enable"`
+	Speed     float64    `json:"speed"`
+	Direction string     `json:"direction"`
+	Random    bool       `json:"random"`
+	Straight  bool       `json:"straight"`
+	OutModes  TSOutModes `json:"outModes"`
+	Attract   TSAttract  `json:"attract"`
+}
+
+// TSCollisions represents the tsParticles collisions configuration
+type TSCollisions struct {
+	Enable bool   `json:"enable"`
+	Mode   string `json:"mode,omitempty"`
+}
+
+// TSParticles represents the tsParticles particles configuration
+type TSParticles struct {
+	Number     TSNumber        `json:"number"`
+	Color      TSColor         `json:"color"`
+	Shape      TSShape         `json:"shape"`
+	Stroke     TSStroke        `json:"stroke"`
+	Opacity    TSAnimatedValue `json:"opacity"`
+	Size       TSAnimatedValue `json:"size"`
+	Links      TSLinks         `json:"links"`
+	Move       TSMove          `json:"move"`
+	Collisions TSCollisions    `json:"collisions"`
+}
+
+// TSEvent represents a tsParticles hover or click event
+type TSEvent struct {
+	Enable bool   `json:"enable"`
+	Mode   string `json:"mode"`
+}
+
+// TSEvents represents the tsParticles interactivity events
+type TSEvents struct {
+	OnHover TSEvent `json:"onHover"`
+	OnClick TSEvent `json:"onClick"`
+	Resize  bool    `json:"resize"`
+}
+
+// TSGrabLinks represents the tsParticles grab links configuration
+type TSGrabLinks struct {
+	Opacity float64 `json:"opacity"`
+}
+
+// TSGrab represents the tsParticles grab mode
+type TSGrab struct {
+	Distance float64     `json:"distance"`
+	Links    TSGrabLinks `json:"links"`
+}
+
+// TSBubble represents the tsParticles bubble mode
+type TSBubble struct {
+	Distance float64 `json:"distance"`
+	Size     float64 `json:"size"`
+	Duration float64 `json:"duration"`
+	Opacity  float64 `json:"opacity"`
+	Speed    float64 `json:"speed"`
+}
+
+// TSRepulse represents the tsParticles repulse mode
+type TSRepulse struct {
+	Distance float64 `json:"distance"`
+	Duration float64 `json:"duration"`
+}
+
+// TSQuantity represents the tsParticles push and remove modes
+type TSQuantity struct {
+	Quantity int `json:"quantity"`
+}
+
+// TSModes represents the tsParticles interactivity modes
+type TSModes struct {
+	Grab    TSGrab     `json:"grab"`
+	Bubble  TSBubble   `json:"bubble"`
+	Repulse TSRepulse  `json:"repulse"`
+	Push    TSQuantity `json:"push"`
+	Remove  TSQuantity `json:"remove"`
+}
+
+// TSInteractivity represents the tsParticles interactivity configuration
+type TSInteractivity struct {
+	DetectsOn string   `json:"detectsOn"`
+	Events    TSEvents `json:"events"`
+	Modes     TSModes  `json:"modes"`
+}
+
+// TSOptions represents the overall tsParticles options
+type TSOptions struct {
+	Particles     TSParticles     `json:"particles"`
+	Interactivity TSInteractivity `json:"interactivity"`
+	DetectRetina  bool            `json:"detectRetina"`
+	// ConfigDemo and Gravity have no tsParticles equivalent, which ignores
+	// them; they are carried along so that converting back loses nothing
+	ConfigDemo *ConfigDemo            `json:"config_demo,omitempty"`
+	Gravity    *physics.GravityConfig `json:"gravity,omitempty"`
+}
+
+// Shape names that differ between particles.js and tsParticles
+var tsShapeNames = map[string]string{
+	"edge": "square",
+}
+
+func convertShapeType(shape interface{}, names map[string]string) interface{} {
+	rename := func(s string) string {
+		if renamed, ok := names[s]; ok {
+			return renamed
+		}
+		return s
+	}
+
+	switch t := shape.(type) {
+	case string:
+		return rename(t)
+	case []string:
+		out := make([]string, len(t))
+		for i, s := range t {
+			out[i] = rename(s)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, item := range t {
+			if s, ok := item.(string); ok {
+				out[i] = rename(s)
+			} else {
+				out[i] = item
+			}
+		}
+		return out
+	default:
+		return shape
+	}
+}
+
+func invertNames(names map[string]string) map[string]string {
+	inverted := make(map[string]string, len(names))
+	for k, v := range names {
+		inverted[v] = k
+	}
+	return inverted
+}
+
+// animatedValue converts a particles.js value with its random flag and
+// animation into a tsParticles value. A random value becomes a range from
+// the animation minimum up to the value.
+func animatedValue(value float64, random bool, anim TSAnimation) TSAnimatedValue {
+	v := TSAnimatedValue{Value: NewTSValue(value), Animation: anim}
+	if random {
+		v.Value.Min = anim.MinimumValue
+		if v.Value.Min >= value {
+			v.Value.Min = 0
+		}
+	}
+	return v
+}
+
+// ToTSParticles converts the configuration to tsParticles options. Every
+// setting particles.js supports has a tsParticles equivalent.
+func (c *Config) ToTSParticles() *TSOptions {
+	p := c.Particles
+	i := c.Interactivity
+
+	o := &TSOptions{DetectRetina: c.RetinaDetect, ConfigDemo: c.ConfigDemo, Gravity: c.Gravity}
+
+	o.Particles.Number = TSNumber{
+		Value:   p.Number.Value,
+		Density: TSDensity{Enable: p.Number.Density.Enable, Area: p.Number.Density.ValueArea},
+	}
+	o.Particles.Color = TSColor{Value: p.Color.Value}
+	o.Particles.Shape = TSShape{
+		Type: convertShapeType(p.Shape.Type, tsShapeNames),
+		Options: TSShapeOptions{
+			Polygon: &TSPolygon{Sides: p.Shape.Polygon.NbSides},
+			Image:   &TSImage{Src: p.Shape.Image.Src, Width: p.Shape.Image.Width, Height: p.Shape.Image.Height},
+		},
+	}
+	o.Particles.Stroke = TSStroke{Width: p.Shape.Stroke.Width, Color: TSColor{Value: p.Shape.Stroke.Color}}
+	o.Particles.Opacity = animatedValue(p.Opacity.Value, p.Opacity.Random, TSAnimation{
+		Enable:       p.Opacity.Anim.Enable,
+		Speed:        p.Opacity.Anim.Speed,
+		MinimumValue: p.Opacity.Anim.OpacityMin,
+		Sync:         p.Opacity.Anim.Sync,
+	})
+	o.Particles.Size = animatedValue(p.Size.Value, p.Size.Random, TSAnimation{
+		Enable:       p.Size.Anim.Enable,
+		Speed:        p.Size.Anim.Speed,
+		MinimumValue: p.Size.Anim.SizeMin,
+		Sync:         p.Size.Anim.Sync,
+	})
+	o.Particles.Links = TSLinks{
+		Enable:   p.LineLinked.Enable,
+		Distance: p.LineLinked.Distance,
+		Color:    TSColor{Value: p.LineLinked.Color},
+		Opacity:  p.LineLinked.Opacity,
+		Width:    p.LineLinked.Width,
+	}
+	o.Particles.Move = TSMove{
+		Enable:    p.Move.Enable,
+		Speed:     p.Move.Speed,
+		Direction: p.Move.Direction,
+		Random:    p.Move.Random,
+		Straight:  p.Move.Straight,
+		OutModes:  TSOutModes{Default: p.Move.OutMode},
+		Attract: TSAttract{
+			Enable: p.Move.Attract.Enable,
+			Rotate: TSRotate{X: p.Move.Attract.RotateX, Y: p.Move.Attract.RotateY},
+		},
+	}
+	// particles.js's move.bounce makes particles bounce off each other
+	o.Particles.Collisions = TSCollisions{Enable: p.Move.Bounce}
+	if p.Move.Bounce {
+		o.Particles.Collisions.Mode = "bounce"
+	}
+
+	o.Interactivity = TSInteractivity{
+		DetectsOn: i.DetectOn,
+		Events: TSEvents{
+			OnHover: TSEvent{Enable: i.Events.OnHover.Enable, Mode: i.Events.OnHover.Mode},
+			OnClick: TSEvent{Enable: i.Events.OnClick.Enable, Mode: i.Events.OnClick.Mode},
+			Resize:  i.Events.Resize,
+		},
+		Modes: TSModes{
+			Grab: TSGrab{
+				Distance: i.Modes.Grab.Distance,
+				Links:    TSGrabLinks{Opacity: i.Modes.Grab.LineLinked.Opacity},
+			},
+			Bubble: TSBubble{
+				Distance: i.Modes.Bubble.Distance,
+				Size:     i.Modes.Bubble.Size,
+				Duration: i.Modes.Bubble.Duration,
+				Opacity:  i.Modes.Bubble.Opacity,
+				Speed:    i.Modes.Bubble.Speed,
+			},
+			Repulse: TSRepulse{Distance: i.Modes.Repulse.Distance, Duration: i.Modes.Repulse.Duration},
+			Push:    TSQuantity{Quantity: i.Modes.Push.ParticlesNb},
+			Remove:  TSQuantity{Quantity: i.Modes.Remove.ParticlesNb},
+		},
+	}
+
+	return o
+}
+
+// ToConfig converts tsParticles options to a particles.js configuration.
+// Settings particles.js has no equivalent for are dropped, and a ranged
+// opacity or size becomes a random value whose animation minimum is the
+// lower end of the range.
+func (o *TSOptions) ToConfig() *Config {
+	p := o.Particles
+	i := o.Interactivity
+
+	c := &Config{RetinaDetect: o.DetectRetina, ConfigDemo: o.ConfigDemo, Gravity: o.Gravity}
+
+	c.Particles.Number = Number{
+		Value:   p.Number.Value,
+		Density: NumberDensity{Enable: p.Number.Density.Enable, ValueArea: p.Number.Density.Area},
+	}
+	c.Particles.Color = Color{Value: p.Color.Value}
+	c.Particles.Shape.Type = convertShapeType(p.Shape.Type, invertNames(tsShapeNames))
+	if poly := p.Shape.Options.Polygon; poly != nil {
+		c.Particles.Shape.Polygon.NbSides = poly.Sides
+	}
+	if img := p.Shape.Options.Image; img != nil {
+		c.Particles.Shape.Image = ShapeImage{Src: img.Src, Width: img.Width, Height: img.Height}
+	}
+	c.Particles.Shape.Stroke.Width = p.Stroke.Width
+	if color, ok := p.Stroke.Color.Value.(string); ok {
+		c.Particles.Shape.Stroke.Color = color
+	}
+
+	c.Particles.Opacity = Opacity{
+		Value:  p.Opacity.Value.Max,
+		Random: p.Opacity.Value.IsRange(),
+		Anim: OpacityAnimation{
+			Enable:     p.Opacity.Animation.Enable,
+			Speed:      p.Opacity.Animation.Speed,
+			OpacityMin: p.Opacity.Animation.MinimumValue,
+			Sync:       p.Opacity.Animation.Sync,
+		},
+	}
+	if p.Opacity.Value.IsRange() && !p.Opacity.Animation.Enable && p.Opacity.Value.Min > 0 {
+		c.Particles.Opacity.Anim.OpacityMin = p.Opacity.Value.Min
+	}
+
+	c.Particles.Size = Size{
+		Value:  p.Size.Value.Max,
+		Random: p.Size.Value.IsRange(),
+		Anim: SizeAnimation{
+			Enable:  p.Size.Animation.Enable,
+			Speed:   p.Size.Animation.Speed,
+			SizeMin: p.Size.Animation.MinimumValue,
+			Sync:    p.Size.Animation.Sync,
+		},
+	}
+	if p.Size.Value.IsRange() && !p.Size.Animation.Enable && p.Size.Value.Min > 0 {
+		c.Particles.Size.Anim.SizeMin = p.Size.Value.Min
+	}
+
+	c.Particles.LineLinked = LineLinked{
+		Enable:   p.Links.Enable,
+		Distance: p.Links.Distance,
+		Opacity:  p.Links.Opacity,
+		Width:    p.Links.Width,
+	}
+	if color, ok := p.Links.Color.Value.(string); ok {
+		c.Particles.LineLinked.Color = color
+	}
+
+	c.Particles.Move = Move{
+		Enable:    p.Move.Enable,
+		Speed:     p.Move.Speed,
+		Direction: p.Move.Direction,
+		Random:    p.Move.Random,
+		Straight:  p.Move.Straight,
+		OutMode:   p.Move.OutModes.Default,
+		Bounce:    p.Collisions.Enable && (p.Collisions.Mode == "" || p.Collisions.Mode == "bounce"),
+		Attract: MoveAttract{
+			Enable:  p.Move.Attract.Enable,
+			RotateX: p.Move.Attract.Rotate.X,
+			RotateY: p.Move.Attract.Rotate.Y,
+		},
+	}
+
+	c.Interactivity = Interactivity{
+		DetectOn: i.DetectsOn,
+		Events: InteractivityEvents{
+			OnHover: InteractivityEventMode{Enable: i.Events.OnHover.Enable, Mode: i.Events.OnHover.Mode},
+			OnClick: InteractivityEventMode{Enable: i.Events.OnClick.Enable, Mode: i.Events.OnClick.Mode},
+			Resize:  i.Events.Resize,
+		},
+		Modes: InteractivityModes{
+			Grab: GrabMode{
+				Distance:   i.Modes.Grab.Distance,
+				LineLinked: GrabLineLinked{Opacity: i.Modes.Grab.Links.Opacity},
+			},
+			Bubble: BubbleMode{
+				Distance: i.Modes.Bubble.Distance,
+				Size:     i.Modes.Bubble.Size,
+				Duration: i.Modes.Bubble.Duration,
+				Opacity:  i.Modes.Bubble.Opacity,
+				Speed:    i.Modes.Bubble.Speed,
+			},
+			Repulse: RepulseMode{Distance: i.Modes.Repulse.Distance, Duration: i.Modes.Repulse.Duration},
+			Push:    PushMode{ParticlesNb: i.Modes.Push.Quantity},
+			Remove:  RemoveMode{ParticlesNb: i.Modes.Remove.Quantity},
+		},
+	}
+
+	return c
+}
+
+// DecodeTSParticles decodes tsParticles JSON options
+func DecodeTSParticles(data []byte) (*TSOptions, error) {
+	options := &TSOptions{}
+	if err := json.Unmarshal(data, options); err != nil {
+		return nil, fmt.Errorf("error decoding tsParticles options: %v", err)
+	}
+	return options, nil
+}
+
+// UnknownTSFields returns the dotted paths of the settings in tsParticles
+// JSON options that TSOptions doesn't represent, sorted. Decoding the
+// options drops them.
+func UnknownTSFields(data []byte) ([]string, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("error decoding tsParticles options: %v", err)
+	}
+	options, err := DecodeTSParticles(data)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+	var known map[string]interface{}
+	if err := json.Unmarshal(encoded, &known); err != nil {
+		return nil, err
+	}
+
+	var unknown []string
+	var walk func(prefix string, raw, known map[string]interface{})
+	walk = func(prefix string, raw, known map[string]interface{}) {
+		for key, value := range raw {
+			k, ok := known[key]
+			if !ok {
+				unknown = append(unknown, prefix+key)
+				continue
+			}
+			if r, ok := value.(map[string]interface{}); ok {
+				if k, ok := k.(map[string]interface{}); ok {
+					walk(prefix+key+".", r, k)
+				}
+			}
+		}
+	}
+	walk("", raw, known)
+	sort.Strings(unknown)
+	return unknown, nil
+}
+
+// DetectFormat guesses whether JSON holds a particles.js configuration or
+// tsParticles options from the keys only one of them uses
+func DetectFormat(data []byte) (string, error) {
+	var doc struct {
+		Particles map[string]json.RawMessage `json:"particles"`
+		Retina    *bool                      `json:"retina_detect"`
+		Detect    *bool                      `json:"detectRetina"`
+		FPSLimit  json.RawMessage            `json:"fpsLimit"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return "", fmt.Errorf("error decoding configuration: %v", err)
+	}
+
+	_, lineLinked := doc.Particles["line_linked"]
+	_, links := doc.Particles["links"]
+	switch {
+	case lineLinked || doc.Retina != nil:
+		return FormatParticlesJS, nil
+	case links || doc.Detect != nil || doc.FPSLimit != nil:
+		return FormatTSParticles, nil
+	}
+	return "", fmt.Errorf("cannot tell whether the configuration is for particles.js or tsParticles")
+}
@@ -0,0 +1,112 @@
+package particles
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/yourusername/particles-go/particles/physics"
+)
+
+func TestTSParticlesRoundTrip(t *testing.T) {
+	for _, name := range PresetNames() {
+		config := GetPreset(name)
+		config.ConfigDemo = &ConfigDemo{HideCard: true, BackgroundColor: "#b61924"}
+		gravity := physics.DefaultGravityConfig()
+		config.Gravity = &gravity
+
+		// Convert through JSON, as the convert command does
+		data, err := json.Marshal(config.ToTSParticles())
+		if err != nil {
+			t.Fatal(err)
+		}
+		options, err := DecodeTSParticles(data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if unknown, err := UnknownTSFields(data); err != nil || len(unknown) > 0 {
+			t.Errorf("%s: unknown fields %v, %v", name, unknown, err)
+		}
+
+		want, _ := json.Marshal(config)
+		got, _ := json.Marshal(options.ToConfig())
+		if string(got) != string(want) {
+			t.Errorf("%s: round trip changed the configuration\ngot  %s\nwant %s", name, got, want)
+		}
+	}
+}
+
+func TestUnknownTSFields(t *testing.T) {
+	tests := []struct {
+		data string
+		want []string
+	}{
+		{`{"particles": {"number": {"value": 10}}}`, nil},
+		{`{"fpsLimit": 60, "particles": {"twinkle": {}, "move": {"speed": 2, "gravity": {"enable": true}}}}`,
+			[]string{"fpsLimit", "particles.move.gravity", "particles.twinkle"}},
+		{`{"interactivity": {"modes": {"trail": {}}}, "background": {"color": "#000"}}`,
+			[]string{"background", "interactivity.modes.trail"}},
+		{`{"config_demo": {"hide_card": true}, "gravity": {"mutual": {"mode": "direct"}}}`, nil},
+	}
+	for _, tt := range tests {
+		got, err := UnknownTSFields([]byte(tt.data))
+		if err != nil {
+			t.Errorf("%s: %v", tt.data, err)
+		} else if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.data, got, tt.want)
+		}
+	}
+	if _, err := UnknownTSFields([]byte(`[1]`)); err == nil {
+		t.Error("non-object options accepted")
+	}
+}
+
+func TestTSRange(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    TSRange
+		isRange bool
+	}{
+		{`3`, NewTSValue(3), false},
+		{`{"min": 1, "max": 5}`, TSRange{Min: 1, Max: 5}, true},
+	}
+	for _, tt := range tests {
+		var r TSRange
+		if err := json.Unmarshal([]byte(tt.data), &r); err != nil {
+			t.Fatalf("%s: %v", tt.data, err)
+		}
+		if r != tt.want || r.IsRange() != tt.isRange {
+			t.Errorf("%s: got %+v", tt.data, r)
+		}
+		data, _ := json.Marshal(r)
+		var back TSRange
+		if err := json.Unmarshal(data, &back); err != nil || back != r {
+			t.Errorf("%s: encoded as %s", tt.data, data)
+		}
+	}
+	var r TSRange
+	if err := json.Unmarshal([]byte(`"3"`), &r); err == nil {
+		t.Error("string accepted as a range")
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{`{"particles": {"line_linked": {}}}`, FormatParticlesJS},
+		{`{"retina_detect": true}`, FormatParticlesJS},
+		{`{"particles": {"links": {}}}`, FormatTSParticles},
+		{`{"detectRetina": true}`, FormatTSParticles},
+		{`{"fpsLimit": 60}`, FormatTSParticles},
+		{`{"particles": {}}`, ""},
+		{`not json`, ""},
+	}
+	for _, tt := range tests {
+		got, err := DetectFormat([]byte(tt.data))
+		if got != tt.want || (err == nil) != (tt.want != "") {
+			t.Errorf("%s: got %q, %v; want %q", tt.data, got, err, tt.want)
+		}
+	}
+}
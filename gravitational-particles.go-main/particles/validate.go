@@ -0,0 +1,357 @@
+package particles
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/particles-go/particles/physics"
+)
+
+// ValidationError describes a single invalid configuration value
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidationErrors collects every problem found in a configuration
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Values accepted by particles.js for enumerated settings
+var (
+	validShapes      = []string{"circle", "edge", "triangle", "polygon", "star", "image"}
+	validDirections  = []string{"none", "top", "top-right", "right", "bottom-right", "bottom", "bottom-left", "left", "top-left"}
+	validOutModes    = []string{"out", "bounce"}
+	validDetectOn    = []string{"canvas", "window"}
+	validHoverModes  = []string{"grab", "bubble", "repulse"}
+	validClickModes  = []string{"push", "remove", "bubble", "repulse"}
+	colorPattern     = regexp.MustCompile(`^(#[0-9a-fA-F]{3}|#[0-9a-fA-F]{6}|random)$`)
+	rgbHSLComponents = []string{"r", "g", "b", "h", "s", "l"}
+)
+
+// Validate checks the configuration for values particles.js can't use.
+// It returns ValidationErrors listing every problem, or nil.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	p := c.Particles
+	v.nonNegative("particles.number.value", float64(p.Number.Value))
+	if p.Number.Density.Enable {
+		v.positive("particles.number.density.value_area", p.Number.Density.ValueArea)
+	}
+	v.color("particles.color.value", p.Color.Value)
+	v.shapeType("particles.shape.type", p.Shape.Type)
+	v.nonNegative("particles.shape.stroke.width", p.Shape.Stroke.Width)
+	v.hexColor("particles.shape.stroke.color", p.Shape.Stroke.Color)
+	v.nonNegative("particles.shape.polygon.nb_sides", float64(p.Shape.Polygon.NbSides))
+	v.unit("particles.opacity.value", p.Opacity.Value)
+	v.unit("particles.opacity.anim.opacity_min", p.Opacity.Anim.OpacityMin)
+	v.nonNegative("particles.opacity.anim.speed", p.Opacity.Anim.Speed)
+	v.nonNegative("particles.size.value", p.Size.Value)
+	v.nonNegative("particles.size.anim.size_min", p.Size.Anim.SizeMin)
+	v.nonNegative("particles.size.anim.speed", p.Size.Anim.Speed)
+	v.nonNegative("particles.line_linked.distance", p.LineLinked.Distance)
+	v.hexColor("particles.line_linked.color", p.LineLinked.Color)
+	v.unit("particles.line_linked.opacity", p.LineLinked.Opacity)
+	v.nonNegative("particles.line_linked.width", p.LineLinked.Width)
+	v.nonNegative("particles.move.speed", p.Move.Speed)
+	v.oneOf("particles.move.direction", p.Move.Direction, validDirections)
+	v.oneOf("particles.move.out_mode", p.Move.OutMode, validOutModes)
+
+	i := c.Interactivity
+	v.oneOf("interactivity.detect_on", i.DetectOn, validDetectOn)
+	if i.Events.OnHover.Enable {
+		v.oneOf("interactivity.events.onhover.mode", i.Events.OnHover.Mode, validHoverModes)
+	}
+	if i.Events.OnClick.Enable {
+		v.oneOf("interactivity.events.onclick.mode", i.Events.OnClick.Mode, validClickModes)
+	}
+	v.nonNegative("interactivity.modes.grab.distance", i.Modes.Grab.Distance)
+	v.nonNegative("interactivity.modes.bubble.distance", i.Modes.Bubble.Distance)
+	v.nonNegative("interactivity.modes.bubble.size", i.Modes.Bubble.Size)
+	v.nonNegative("interactivity.modes.bubble.duration", i.Modes.Bubble.Duration)
+	v.nonNegative("interactivity.modes.repulse.distance", i.Modes.Repulse.Distance)
+	v.nonNegative("interactivity.modes.repulse.duration", i.Modes.Repulse.Duration)
+	v.nonNegative("interactivity.modes.push.particles_nb", float64(i.Modes.Push.ParticlesNb))
+	v.nonNegative("interactivity.modes.remove.particles_nb", float64(i.Modes.Remove.ParticlesNb))
+
+	if c.Gravity != nil {
+		v.gravity("gravity", c.Gravity)
+	}
+
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// gravity checks the settings of the gravity simulation
+func (v *validator) gravity(path string, g *physics.GravityConfig) {
+	v.between(path+".sun.mass", g.Sun.Mass, 0, physics.MaxMass)
+	v.nonNegative(path+".sun.radius", g.Sun.Radius)
+	v.hexColor(path+".sun.color", g.Sun.Color)
+
+	p := g.Planets
+	if p.Count < 0 || p.Count > physics.MaxPlanets {
+		v.add(path+".planets.count", "must be between 0 and %d, got %d", physics.MaxPlanets, p.Count)
+	}
+	v.nonNegative(path+".planets.min_distance", p.MinDistance)
+	if p.MaxDistance < p.MinDistance {
+		v.add(path+".planets.max_distance", "must not be less than min_distance, got %v", p.MaxDistance)
+	}
+	v.nonNegative(path+".planets.min_radius", p.MinRadius)
+	if p.MaxRadius < p.MinRadius {
+		v.add(path+".planets.max_radius", "must not be less than min_radius, got %v", p.MaxRadius)
+	}
+	v.between(path+".planets.mass", p.Mass, 0, physics.MaxMass)
+	v.nonNegative(path+".planets.speed_factor", p.SpeedFactor)
+	for i, color := range p.Colors {
+		v.hexColor(fmt.Sprintf("%s.planets.colors[%d]", path, i), color)
+	}
+
+	v.between(path+".force_scale", g.ForceScale, 0, physics.MaxForceScale)
+	v.unit(path+".damping", g.Damping)
+	v.unit(path+".edge_restitution", g.EdgeRestitution)
+	v.oneOf(path+".boundary.mode", g.Boundary.Mode, physics.BoundaryModes())
+	v.nonNegative(path+".boundary.margin", g.Boundary.Margin)
+	v.nonNegative(path+".boundary.stiffness", g.Boundary.Stiffness)
+	v.nonNegative(path+".cursor.radius", g.Cursor.Radius)
+	v.nonNegative(path+".cursor.bounce_factor", g.Cursor.BounceFactor)
+	v.nonNegative(path+".cursor.push_out", g.Cursor.PushOut)
+	v.nonNegative(path+".cursor.flash_steps", float64(g.Cursor.FlashSteps))
+	v.oneOf(path+".collision.mode", g.Collision.Mode, []string{physics.CollisionDiscrete, physics.CollisionContinuous})
+	v.nonNegative(path+".collision.cursor_restitution", g.Collision.CursorRestitution)
+	v.unit(path+".collision.sun_restitution", g.Collision.SunRestitution)
+	v.unit(path+".collision.body_restitution", g.Collision.BodyRestitution)
+	v.oneOf(path+".mutual.mode", g.Mutual.Mode, []string{physics.MutualOff, physics.MutualDirect, physics.MutualBarnesHut})
+	v.nonNegative(path+".mutual.theta", g.Mutual.Theta)
+	if g.Mutual.Mode == physics.MutualDirect || g.Mutual.Mode == physics.MutualBarnesHut {
+		// Planets passing through each other would otherwise be flung off
+		// at infinite speed
+		v.positive(path+".mutual.softening", g.Mutual.Softening)
+	} else {
+		v.nonNegative(path+".mutual.softening", g.Mutual.Softening)
+	}
+	v.oneOf(path+".integrator", g.Integrator, physics.IntegratorNames())
+	if g.Substeps < 0 || g.Substeps > physics.MaxSubsteps {
+		v.add(path+".substeps", "must be between 0 and %d, got %d", physics.MaxSubsteps, g.Substeps)
+	}
+	for i, a := range g.Attractors {
+		attractor := fmt.Sprintf("%s.attractors[%d]", path, i)
+		v.between(attractor+".mass", a.Mass, -physics.MaxMass, physics.MaxMass)
+		v.nonNegative(attractor+".radius", a.Radius)
+		v.hexColor(attractor+".color", a.Color)
+		if a.Path != nil {
+			v.oneOf(attractor+".path.type", a.Path.Type, []string{physics.PathOrbit, physics.PathLine})
+			v.positive(attractor+".path.period", a.Path.Period)
+			v.nonNegative(attractor+".path.radius", a.Path.Radius)
+		}
+	}
+	for i, f := range g.Fields {
+		field := fmt.Sprintf("%s.fields[%d]", path, i)
+		if f.Type == "" {
+			v.add(field+".type", "must be set")
+		}
+		v.oneOf(field+".type", f.Type, []string{physics.FieldUniform, physics.FieldVortex})
+		v.between(field+".ax", f.AX, -physics.MaxFieldAcceleration, physics.MaxFieldAcceleration)
+		v.between(field+".ay", f.AY, -physics.MaxFieldAcceleration, physics.MaxFieldAcceleration)
+		v.between(field+".strength", f.Strength, -physics.MaxFieldAcceleration, physics.MaxFieldAcceleration)
+		v.nonNegative(field+".radius", f.Radius)
+	}
+	for i, e := range g.Emitters {
+		emitter := fmt.Sprintf("%s.emitters[%d]", path, i)
+		if e.Shape == "" {
+			v.add(emitter+".shape", "must be set")
+		}
+		v.oneOf(emitter+".shape", e.Shape, []string{physics.EmitterPoint, physics.EmitterLine, physics.EmitterArea})
+		if e.Path != nil {
+			v.oneOf(emitter+".path.type", e.Path.Type, []string{physics.PathOrbit, physics.PathLine})
+			v.positive(emitter+".path.period", e.Path.Period)
+			v.nonNegative(emitter+".path.radius", e.Path.Radius)
+		}
+		if e.Rate < 0 || e.Rate > physics.MaxEmitterRate {
+			v.add(emitter+".rate", "must be between 0 and %d, got %v", physics.MaxEmitterRate, e.Rate)
+		}
+		if e.Burst < 0 || e.Burst > physics.MaxPlanets {
+			v.add(emitter+".burst", "must be between 0 and %d, got %d", physics.MaxPlanets, e.Burst)
+		}
+		v.nonNegative(emitter+".interval", float64(e.Interval))
+		if e.Spread < 0 || e.Spread > 360 {
+			v.add(emitter+".spread", "must be between 0 and 360, got %v", e.Spread)
+		}
+		v.nonNegative(emitter+".min_speed", e.MinSpeed)
+		if e.MaxSpeed < e.MinSpeed {
+			v.add(emitter+".max_speed", "must not be less than min_speed, got %v", e.MaxSpeed)
+		}
+		v.nonNegative(emitter+".min_radius", e.MinRadius)
+		if e.MaxRadius < e.MinRadius {
+			v.add(emitter+".max_radius", "must not be less than min_radius, got %v", e.MaxRadius)
+		}
+		v.between(emitter+".mass", e.Mass, 0, physics.MaxMass)
+		for j, color := range e.Colors {
+			v.hexColor(fmt.Sprintf("%s.colors[%d]", emitter, j), color)
+		}
+		v.nonNegative(emitter+".lifetime", float64(e.Lifetime))
+		v.nonNegative(emitter+".fade_out", float64(e.FadeOut))
+	}
+	if g.MaxBodies < 0 || g.MaxBodies > physics.MaxPlanets {
+		v.add(path+".max_bodies", "must be between 0 and %d, got %d", physics.MaxPlanets, g.MaxBodies)
+	} else if g.MaxBodies == 0 && len(g.Emitters) > 0 {
+		// Emitters would otherwise add planets until the server runs out
+		// of memory
+		v.add(path+".max_bodies", "must be set when there are emitters")
+	}
+	if g.Warmup.Steps < 0 || g.Warmup.Steps > physics.MaxWarmupSteps {
+		v.add(path+".warmup.steps", "must be between 0 and %d, got %d", physics.MaxWarmupSteps, g.Warmup.Steps)
+	}
+	v.positive(path+".warmup.width", g.Warmup.Width)
+	v.positive(path+".warmup.height", g.Warmup.Height)
+	if g.State != nil {
+		if len(g.State.Bodies) > physics.MaxPlanets {
+			v.add(path+".state.bodies", "must hold at most %d planets, got %d", physics.MaxPlanets, len(g.State.Bodies))
+		}
+		for i, b := range g.State.Bodies {
+			body := fmt.Sprintf("%s.state.bodies[%d]", path, i)
+			v.nonNegative(body+".radius", b.Radius)
+			v.nonNegative(body+".mass", b.Mass)
+			v.hexColor(body+".color", b.Color)
+		}
+		for i, e := range g.State.Emitted {
+			v.unit(fmt.Sprintf("%s.state.emitted[%d]", path, i), e)
+		}
+	}
+}
+
+type validator struct {
+	errs ValidationErrors
+}
+
+func (v *validator) add(path, format string, args ...interface{}) {
+	v.errs = append(v.errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) nonNegative(path string, value float64) {
+	if value < 0 {
+		v.add(path, "must not be negative, got %v", value)
+	}
+}
+
+func (v *validator) positive(path string, value float64) {
+	if value <= 0 {
+		v.add(path, "must be positive, got %v", value)
+	}
+}
+
+func (v *validator) between(path string, value, min, max float64) {
+	if value < min || value > max {
+		v.add(path, "must be between %v and %v, got %v", min, max, value)
+	}
+}
+
+func (v *validator) unit(path string, value float64) {
+	if value < 0 || value > 1 {
+		v.add(path, "must be between 0 and 1, got %v", value)
+	}
+}
+
+// oneOf accepts an empty value, which particles.js replaces with its default
+func (v *validator) oneOf(path, value string, allowed []string) {
+	if value == "" {
+		return
+	}
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.add(path, "unknown value %q, expected one of %s", value, strings.Join(allowed, ", "))
+}
+
+func (v *validator) hexColor(path, value string) {
+	if value != "" && !colorPattern.MatchString(value) {
+		v.add(path, "invalid color %q", value)
+	}
+}
+
+// color accepts the forms particles.js supports for particles.color.value:
+// a hex string, "random", a list of hex strings or an rgb/hsl object
+func (v *validator) color(path string, value interface{}) {
+	switch c := value.(type) {
+	case nil:
+	case string:
+		v.hexColor(path, c)
+	case []string:
+		v.colorList(path, len(c), func(i int) interface{} { return c[i] })
+	case []interface{}:
+		v.colorList(path, len(c), func(i int) interface{} { return c[i] })
+	case map[string]interface{}:
+		for key := range c {
+			if !containsString(rgbHSLComponents, key) {
+				v.add(path, "unknown color component %q", key)
+			}
+		}
+	default:
+		v.add(path, "unsupported color value %v", value)
+	}
+}
+
+func (v *validator) colorList(path string, n int, item func(int) interface{}) {
+	if n == 0 {
+		v.add(path, "color list must not be empty")
+	}
+	for i := 0; i < n; i++ {
+		s, ok := item(i).(string)
+		if !ok {
+			v.add(fmt.Sprintf("%s[%d]", path, i), "expected a color string")
+			continue
+		}
+		v.hexColor(fmt.Sprintf("%s[%d]", path, i), s)
+	}
+}
+
+func (v *validator) shapeType(path string, value interface{}) {
+	switch t := value.(type) {
+	case nil:
+	case string:
+		v.oneOf(path, t, validShapes)
+	case []string:
+		for i, s := range t {
+			v.oneOf(fmt.Sprintf("%s[%d]", path, i), s, validShapes)
+		}
+	case []interface{}:
+		for i, item := range t {
+			s, ok := item.(string)
+			if !ok {
+				v.add(fmt.Sprintf("%s[%d]", path, i), "expected a shape name")
+				continue
+			}
+			v.oneOf(fmt.Sprintf("%s[%d]", path, i), s, validShapes)
+		}
+	default:
+		v.add(path, "unsupported shape value %v", value)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package particles
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/particles-go/particles/physics"
+)
+
+// errorPaths returns the paths of the validation errors err lists
+func errorPaths(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("got %T, want ValidationErrors: %v", err, err)
+	}
+	paths := make([]string, len(errs))
+	for i, e := range errs {
+		paths[i] = e.Path
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestValidateGravityLimits(t *testing.T) {
+	emitter := physics.EmitterConfig{Shape: physics.EmitterPoint, Rate: 1}
+
+	tests := []struct {
+		desc   string
+		modify func(g *physics.GravityConfig)
+		paths  []string
+	}{
+		{"defaults", func(g *physics.GravityConfig) {}, nil},
+		{"most planets", func(g *physics.GravityConfig) { g.Planets.Count = physics.MaxPlanets }, nil},
+		{"too many planets", func(g *physics.GravityConfig) { g.Planets.Count = physics.MaxPlanets + 1 }, []string{"gravity.planets.count"}},
+		{"negative planets", func(g *physics.GravityConfig) { g.Planets.Count = -1 }, []string{"gravity.planets.count"}},
+		{"emitter", func(g *physics.GravityConfig) {
+			g.Emitters = []physics.EmitterConfig{emitter}
+		}, nil},
+		{"emitter rate too high", func(g *physics.GravityConfig) {
+			e := emitter
+			e.Rate = physics.MaxEmitterRate + 1
+			g.Emitters = []physics.EmitterConfig{e}
+		}, []string{"gravity.emitters[0].rate"}},
+		{"emitter burst too large", func(g *physics.GravityConfig) {
+			e := emitter
+			e.Burst = physics.MaxPlanets + 1
+			g.Emitters = []physics.EmitterConfig{e}
+		}, []string{"gravity.emitters[0].burst"}},
+		{"emitters without max_bodies", func(g *physics.GravityConfig) {
+			g.MaxBodies = 0
+			g.Emitters = []physics.EmitterConfig{emitter}
+		}, []string{"gravity.max_bodies"}},
+		{"no limit without emitters", func(g *physics.GravityConfig) { g.MaxBodies = 0 }, nil},
+		{"max_bodies too large", func(g *physics.GravityConfig) { g.MaxBodies = physics.MaxPlanets + 1 }, []string{"gravity.max_bodies"}},
+		{"state too large", func(g *physics.GravityConfig) {
+			g.State = &physics.State{Bodies: make([]physics.Body, physics.MaxPlanets+1)}
+		}, []string{"gravity.state.bodies"}},
+		{"state owing too much", func(g *physics.GravityConfig) {
+			g.State = &physics.State{Emitted: []float64{0.5, 2}}
+		}, []string{"gravity.state.emitted[1]"}},
+		{"warm-up too long", func(g *physics.GravityConfig) { g.Warmup.Steps = physics.MaxWarmupSteps + 1 }, []string{"gravity.warmup.steps"}},
+		{"mutual gravity", func(g *physics.GravityConfig) { g.Mutual.Mode = physics.MutualBarnesHut }, nil},
+		{"mutual gravity without softening", func(g *physics.GravityConfig) {
+			g.Mutual.Mode = physics.MutualDirect
+			g.Mutual.Softening = 0
+		}, []string{"gravity.mutual.softening"}},
+		{"no softening without mutual gravity", func(g *physics.GravityConfig) { g.Mutual.Softening = 0 }, nil},
+		{"heaviest sun", func(g *physics.GravityConfig) { g.Sun.Mass = physics.MaxMass }, nil},
+		{"sun too heavy", func(g *physics.GravityConfig) { g.Sun.Mass = 1e308 }, []string{"gravity.sun.mass"}},
+		{"force_scale too large", func(g *physics.GravityConfig) { g.ForceScale = 1e308 }, []string{"gravity.force_scale"}},
+		{"attractor too heavy", func(g *physics.GravityConfig) {
+			g.Attractors = []physics.AttractorConfig{{Mass: -physics.MaxMass}, {Mass: physics.MaxMass * 2}}
+		}, []string{"gravity.attractors[1].mass"}},
+		{"field too strong", func(g *physics.GravityConfig) {
+			g.Fields = []physics.FieldConfig{
+				{Type: physics.FieldUniform, AY: -physics.MaxFieldAcceleration * 2},
+				{Type: physics.FieldVortex, Strength: 1e308},
+			}
+		}, []string{"gravity.fields[0].ay", "gravity.fields[1].strength"}},
+		{"too many substeps", func(g *physics.GravityConfig) { g.Substeps = physics.MaxSubsteps + 1 }, []string{"gravity.substeps"}},
+	}
+	for _, tt := range tests {
+		config := DefaultConfig()
+		gravity := physics.DefaultGravityConfig()
+		tt.modify(&gravity)
+		config.Gravity = &gravity
+
+		paths := errorPaths(t, config.Validate())
+		if strings.Join(paths, ",") != strings.Join(tt.paths, ",") {
+			t.Errorf("%s: got errors at %v, want %v", tt.desc, paths, tt.paths)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package particles
+
+import (
+	"container/list"
+	"encoding/json"
+	"sync"
+
+	"github.com/yourusername/particles-go/particles/physics"
+)
+
+// maxWarmups bounds the warmed-up states kept in memory
+const maxWarmups = 64
+
+// warmupCache holds warmed-up states by gravity settings, as every visitor
+// gets the same one, evicting the least recently used
+type warmupCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // of *warmupEntry, most recently used first
+	entries  map[string]*list.Element
+	running  map[string]*warmupCall
+	warmUp   func(physics.GravityConfig) (*physics.State, error)
+}
+
+type warmupEntry struct {
+	key   string
+	state *physics.State
+}
+
+// warmupCall is a warm-up in progress; concurrent requests for the same
+// settings wait for it instead of running their own
+type warmupCall struct {
+	done  chan struct{}
+	state *physics.State
+}
+
+var warmups = newWarmupCache(maxWarmups)
+
+func newWarmupCache(capacity int) *warmupCache {
+	return &warmupCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		running:  make(map[string]*warmupCall),
+		warmUp:   physics.WarmUp,
+	}
+}
+
+// state returns the warmed-up state of settings. On a miss it calls allow,
+// when set, and returns nil without warming up if that refuses.
+func (c *warmupCache) state(settings physics.GravityConfig, allow func() bool) *physics.State {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil
+	}
+	key := string(data)
+
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.order.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*warmupEntry).state
+	}
+	if call, ok := c.running[key]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return call.state
+	}
+	if allow != nil && !allow() {
+		c.mu.Unlock()
+		return nil
+	}
+	call := &warmupCall{done: make(chan struct{})}
+	c.running[key] = call
+	c.mu.Unlock()
+
+	// Release waiting callers even if the warm-up panics, so that they
+	// and later callers don't block forever
+	defer func() {
+		c.mu.Lock()
+		delete(c.running, key)
+		c.mu.Unlock()
+		close(call.done)
+	}()
+
+	// A warm-up that diverges is not cached, so that callers fall back to
+	// the configuration as it is
+	state, err := c.warmUp(settings)
+	if err != nil {
+		return nil
+	}
+	call.state = state
+
+	c.mu.Lock()
+	c.entries[key] = c.order.PushFront(&warmupEntry{key, state})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*warmupEntry).key)
+	}
+	c.mu.Unlock()
+	return state
+}
+
+// needsWarmUp reports whether c asks for a warm-up it doesn't have yet
+func (c *Config) needsWarmUp() bool {
+	return c.Gravity != nil && c.Gravity.Warmup.Steps > 0 && c.Gravity.State == nil
+}
+
+// WarmUp returns the configuration with the settled state of its gravity
+// simulation filled in, so that browsers start from it instead of random
+// orbits. Configurations without a warm-up, or with a state already, are
+// returned as they are; c itself is never modified.
+func (c *Config) WarmUp() *Config {
+	return c.warmUp(nil)
+}
+
+// warmUp is WarmUp, except that a state not cached yet is only computed
+// if allow, when set, agrees; otherwise c is returned as it is
+func (c *Config) warmUp(allow func() bool) *Config {
+	if !c.needsWarmUp() {
+		return c
+	}
+	state := warmups.state(c.GravitySettings(), allow)
+	if state == nil {
+		return c
+	}
+	clone, err := c.Clone()
+	if err != nil {
+		return c
+	}
+	clone.Gravity.State = state
+	return clone
+}
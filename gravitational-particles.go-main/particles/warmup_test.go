@@ -0,0 +1,180 @@
+package particles
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourusername/particles-go/particles/physics"
+)
+
+func warmupConfig(seed int64) *Config {
+	config := DefaultConfig()
+	gravity := physics.DefaultGravityConfig()
+	gravity.Planets.Count = 5
+	gravity.Warmup.Steps = 20
+	gravity.Warmup.Seed = seed
+	config.Gravity = &gravity
+	return config
+}
+
+func TestWarmUp(t *testing.T) {
+	config := warmupConfig(1)
+	warmed := config.WarmUp()
+	if config.Gravity.State != nil {
+		t.Error("WarmUp modified its config")
+	}
+	if warmed.Gravity.State == nil || warmed.Gravity.State.Step != 20 {
+		t.Fatalf("got state %+v", warmed.Gravity.State)
+	}
+	if again := config.WarmUp(); again.Gravity.State != warmed.Gravity.State {
+		t.Error("state was not cached")
+	}
+	if warmed.WarmUp() != warmed {
+		t.Error("config with a state warmed up again")
+	}
+	if plain := DefaultConfig(); plain.WarmUp() != plain {
+		t.Error("config without a warm-up was copied")
+	}
+}
+
+func TestWarmUpDiverged(t *testing.T) {
+	// Forces past the validated limits fling planets to infinity
+	preset := `{"particles": {"number": {"value": 10}}, "gravity": {"sun": {"mass": 1e308}, "force_scale": 1e308, "warmup": {"steps": 3}}}`
+	if _, err := DecodeConfig([]byte(preset)); err == nil || !strings.Contains(err.Error(), "gravity.sun.mass") {
+		t.Errorf("got %v, want the sun's mass refused", err)
+	}
+
+	config := warmupConfig(4)
+	config.Gravity.Sun.Mass = 1e308
+	config.Gravity.ForceScale = 1e308
+	if got := config.WarmUp(); got != config || got.Gravity.State != nil {
+		t.Error("diverged warm-up was kept")
+	}
+	if err := RegisterPreset("diverged", config); err != nil {
+		t.Fatal(err)
+	}
+	defer UnregisterPreset("diverged")
+	if got := GetPreset("diverged"); got.Gravity.State != nil {
+		t.Error("diverged warm-up was kept")
+	}
+
+	config.Particles.Size.Value = math.NaN()
+	if err := RegisterPreset("nan", config); err == nil {
+		UnregisterPreset("nan")
+		t.Error("config that can't be copied was registered")
+	}
+	if _, err := config.Clone(); err == nil {
+		t.Error("NaN was copied")
+	}
+}
+
+func TestWarmUpRefused(t *testing.T) {
+	config := warmupConfig(2)
+	if got := config.warmUp(func() bool { return false }); got != config {
+		t.Error("refused warm-up ran")
+	}
+	warmed := config.warmUp(func() bool { return true })
+	if warmed.Gravity.State == nil {
+		t.Fatal("allowed warm-up didn't run")
+	}
+
+	// Cached states are served without asking
+	got := config.warmUp(func() bool {
+		t.Error("asked to warm up a cached state")
+		return false
+	})
+	if got.Gravity.State != warmed.Gravity.State {
+		t.Error("cached state not served")
+	}
+}
+
+func TestWarmupCacheSharesRuns(t *testing.T) {
+	cache := newWarmupCache(4)
+	settings := warmupConfig(3).GravitySettings()
+
+	var mu sync.Mutex
+	runs := 0
+	allow := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		runs++
+		return true
+	}
+
+	var wg sync.WaitGroup
+	states := make([]*physics.State, 8)
+	for i := range states {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			states[i] = cache.state(settings, allow)
+		}(i)
+	}
+	wg.Wait()
+
+	if runs != 1 {
+		t.Errorf("warmed up %d times", runs)
+	}
+	for _, s := range states {
+		if s == nil || s != states[0] {
+			t.Fatal("concurrent warm-ups got different states")
+		}
+	}
+}
+
+func TestWarmupCachePanic(t *testing.T) {
+	cache := newWarmupCache(4)
+	cache.warmUp = func(physics.GravityConfig) (*physics.State, error) { panic("boom") }
+	settings := warmupConfig(5).GravitySettings()
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("warm-up didn't panic")
+			}
+		}()
+		cache.state(settings, nil)
+	}()
+	if len(cache.running) != 0 {
+		t.Fatal("panicked warm-up is still running")
+	}
+
+	// The next caller runs its own warm-up instead of waiting forever
+	cache.warmUp = physics.WarmUp
+	done := make(chan *physics.State)
+	go func() { done <- cache.state(settings, nil) }()
+	select {
+	case state := <-done:
+		if state == nil {
+			t.Error("warm-up after a panic failed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("warm-up after a panic blocked")
+	}
+}
+
+func TestWarmupCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newWarmupCache(2)
+	a := warmupConfig(10).GravitySettings()
+	b := warmupConfig(11).GravitySettings()
+	c := warmupConfig(12).GravitySettings()
+
+	stateA := cache.state(a, nil)
+	cache.state(b, nil)
+	cache.state(a, nil) // a is now more recent than b
+	cache.state(c, nil)
+
+	refuse := func() bool { return false }
+	if cache.state(a, refuse) != stateA {
+		t.Error("recently used state evicted")
+	}
+	if cache.state(b, refuse) != nil {
+		t.Error("least recently used state kept")
+	}
+	if len(cache.entries) != 2 || cache.order.Len() != 2 {
+		t.Errorf("cache holds %d entries", len(cache.entries))
+	}
+}
@@ -0,0 +1,396 @@
+package particles
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseYAML decodes the subset of YAML used in Hugo front matter: nested
+// block mappings, block and flow sequences, literal (|) and folded (>)
+// block scalars and plain or quoted scalars. Anchors and multi-document
+// streams are not supported.
+func parseYAML(data []byte) (map[string]interface{}, error) {
+	var lines []yamlLine
+	raws := strings.Split(string(data), "\n")
+	for i, raw := range raws {
+		text := strings.TrimRight(stripYAMLComment(raw), " \t\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		if strings.HasPrefix(text[indent:], "\t") {
+			return nil, fmt.Errorf("yaml: line %d: tabs are not allowed for indentation", i+1)
+		}
+		lines = append(lines, yamlLine{number: i + 1, indent: indent, text: text[indent:]})
+	}
+
+	p := &yamlParser{lines: lines, raw: raws}
+	if len(lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	value, err := p.parseBlock(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("yaml: line %d: unexpected indentation", p.lines[p.pos].number)
+	}
+	root, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("yaml: document is not a mapping")
+	}
+	return root, nil
+}
+
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	raw   []string // Every line, for block scalars
+	pos   int
+}
+
+// parseBlock parses the mapping or sequence whose entries sit at indent
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	if strings.HasPrefix(p.lines[p.pos].text, "- ") || p.lines[p.pos].text == "-" {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+func (p *yamlParser) parseMapping(indent int) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("yaml: line %d: unexpected indentation", line.number)
+		}
+
+		key, rest, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("yaml: line %d: expected 'key: value'", line.number)
+		}
+		if _, exists := m[key]; exists {
+			return nil, fmt.Errorf("yaml: line %d: duplicate key %q", line.number, key)
+		}
+		p.pos++
+
+		value, err := p.parseValue(line, indent, rest)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = value
+	}
+	return m, nil
+}
+
+func (p *yamlParser) parseSequence(indent int) ([]interface{}, error) {
+	var seq []interface{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent || !(strings.HasPrefix(line.text, "- ") || line.text == "-") {
+			return nil, fmt.Errorf("yaml: line %d: expected sequence entry", line.number)
+		}
+		rest := strings.TrimSpace(strings.TrimPrefix(line.text, "-"))
+
+		// "- key: value" starts a mapping nested in the sequence entry
+		if _, _, isMap := splitYAMLKey(rest); isMap && !strings.HasPrefix(rest, "{") {
+			entryIndent := indent + (len(line.text) - len(strings.TrimLeft(line.text[1:], " ")))
+			p.lines[p.pos] = yamlLine{number: line.number, indent: entryIndent, text: rest}
+			m, err := p.parseMapping(entryIndent)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, m)
+			continue
+		}
+
+		p.pos++
+		value, err := p.parseValue(line, indent, rest)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, value)
+	}
+	return seq, nil
+}
+
+// parseValue parses the value following a key or sequence marker. An
+// empty value introduces a nested block on the following lines.
+func (p *yamlParser) parseValue(line yamlLine, indent int, rest string) (interface{}, error) {
+	if rest != "" {
+		if strings.HasPrefix(rest, "|") || strings.HasPrefix(rest, ">") {
+			return p.parseBlockScalar(line, indent, rest)
+		}
+		value, err := parseYAMLFlow(rest)
+		if err != nil {
+			return nil, fmt.Errorf("yaml: line %d: %v", line.number, err)
+		}
+		return value, nil
+	}
+
+	if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+		return p.parseBlock(p.lines[p.pos].indent)
+	}
+	// Sequences may sit at the same indentation as their key
+	if p.pos < len(p.lines) && p.lines[p.pos].indent == indent && strings.HasPrefix(p.lines[p.pos].text, "- ") {
+		return p.parseSequence(indent)
+	}
+	return nil, nil
+}
+
+// parseBlockScalar parses a literal (|) or folded (>) block scalar whose
+// header ends line. Its text is the following lines indented more than
+// the key, read raw since comments and blank lines in it are text.
+func (p *yamlParser) parseBlockScalar(line yamlLine, indent int, header string) (interface{}, error) {
+	folded := header[0] == '>'
+	chomp := byte(0)
+	explicit := 0
+	for _, c := range header[1:] {
+		switch {
+		case (c == '-' || c == '+') && chomp == 0:
+			chomp = byte(c)
+		case c >= '1' && c <= '9' && explicit == 0:
+			explicit = int(c - '0')
+		default:
+			return nil, fmt.Errorf("yaml: line %d: invalid block scalar header %q", line.number, header)
+		}
+	}
+
+	// line.number is 1-based, so it indexes the line after the header
+	var body []string
+	blockIndent := 0
+	if explicit > 0 {
+		blockIndent = indent + explicit
+	}
+	last := line.number
+	for n := line.number; n < len(p.raw); n++ {
+		text := strings.TrimRight(p.raw[n], "\r")
+		lineIndent := len(text) - len(strings.TrimLeft(text, " "))
+		if strings.TrimSpace(text) == "" {
+			body = append(body, "")
+			continue
+		}
+		if lineIndent <= indent {
+			break
+		}
+		if blockIndent == 0 {
+			blockIndent = lineIndent
+		}
+		if lineIndent < blockIndent {
+			return nil, fmt.Errorf("yaml: line %d: block scalar is less indented than its first line", n+1)
+		}
+		body = append(body, text[blockIndent:])
+		last = n + 1
+	}
+	for p.pos < len(p.lines) && p.lines[p.pos].number <= last {
+		p.pos++
+	}
+
+	// Trailing blank lines only count when kept
+	content := len(body)
+	for content > 0 && body[content-1] == "" {
+		content--
+	}
+	trailing := len(body) - content
+	body = body[:content]
+
+	var b strings.Builder
+	for i, text := range body {
+		if i > 0 {
+			// Folding joins lines of text with spaces; blank lines and
+			// more indented lines keep their line breaks
+			if folded && text != "" && body[i-1] != "" && !strings.HasPrefix(text, " ") && !strings.HasPrefix(body[i-1], " ") {
+				b.WriteByte(' ')
+			} else if !(folded && text == "" && i+1 < len(body) && body[i-1] != "") {
+				b.WriteByte('\n')
+			}
+		}
+		b.WriteString(text)
+	}
+	switch {
+	case content == 0 || chomp == '-':
+	case chomp == '+':
+		b.WriteString(strings.Repeat("\n", trailing+1))
+	default:
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// splitYAMLKey splits "key: value" into its key and value
+func splitYAMLKey(text string) (string, string, bool) {
+	if strings.HasPrefix(text, `"`) || strings.HasPrefix(text, `'`) {
+		end := strings.IndexByte(text[1:], text[0])
+		if end < 0 {
+			return "", "", false
+		}
+		key := text[1 : end+1]
+		rest := text[end+2:]
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", false
+		}
+		return key, strings.TrimSpace(rest[1:]), true
+	}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i == len(text)-1 || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// stripYAMLComment removes a trailing comment outside of quotes
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseYAMLFlow parses a scalar or a flow collection such as [a, b] or {k: v}
+func parseYAMLFlow(text string) (interface{}, error) {
+	text = strings.TrimSpace(text)
+	if !strings.ContainsAny(text[:1], `[{"'`) {
+		return yamlScalar(text), nil
+	}
+
+	value, rest, err := parseYAMLFlowValue(text)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(rest) != "" {
+		return nil, fmt.Errorf("unexpected %q", rest)
+	}
+	return value, nil
+}
+
+func parseYAMLFlowValue(text string) (interface{}, string, error) {
+	text = strings.TrimLeft(text, " ")
+	if text == "" {
+		return nil, "", nil
+	}
+
+	switch text[0] {
+	case '[':
+		seq := []interface{}{}
+		text = strings.TrimLeft(text[1:], " ")
+		for {
+			if strings.HasPrefix(text, "]") {
+				return seq, text[1:], nil
+			}
+			value, rest, err := parseYAMLFlowValue(text)
+			if err != nil {
+				return nil, "", err
+			}
+			seq = append(seq, value)
+			text = strings.TrimLeft(rest, " ")
+			if strings.HasPrefix(text, ",") {
+				text = strings.TrimLeft(text[1:], " ")
+			} else if !strings.HasPrefix(text, "]") {
+				return nil, "", fmt.Errorf("unterminated flow sequence")
+			}
+		}
+	case '{':
+		m := make(map[string]interface{})
+		text = strings.TrimLeft(text[1:], " ")
+		for {
+			if strings.HasPrefix(text, "}") {
+				return m, text[1:], nil
+			}
+			colon := strings.Index(text, ":")
+			if colon < 0 {
+				return nil, "", fmt.Errorf("expected 'key: value' in flow mapping")
+			}
+			key := strings.Trim(strings.TrimSpace(text[:colon]), `"'`)
+			value, rest, err := parseYAMLFlowValue(text[colon+1:])
+			if err != nil {
+				return nil, "", err
+			}
+			m[key] = value
+			text = strings.TrimLeft(rest, " ")
+			if strings.HasPrefix(text, ",") {
+				text = strings.TrimLeft(text[1:], " ")
+			} else if !strings.HasPrefix(text, "}") {
+				return nil, "", fmt.Errorf("unterminated flow mapping")
+			}
+		}
+	case '"':
+		for i := 1; i < len(text); i++ {
+			if text[i] == '\\' {
+				i++
+				continue
+			}
+			if text[i] == '"' {
+				s, err := strconv.Unquote(text[:i+1])
+				if err != nil {
+					return nil, "", fmt.Errorf("invalid string %s", text[:i+1])
+				}
+				return s, text[i+1:], nil
+			}
+		}
+		return nil, "", fmt.Errorf("unterminated string")
+	case '\'':
+		var b strings.Builder
+		for i := 1; i < len(text); i++ {
+			if text[i] == '\'' {
+				if i+1 < len(text) && text[i+1] == '\'' {
+					b.WriteByte('\'')
+					i++
+					continue
+				}
+				return b.String(), text[i+1:], nil
+			}
+			b.WriteByte(text[i])
+		}
+		return nil, "", fmt.Errorf("unterminated string")
+	}
+
+	end := strings.IndexAny(text, ",]}")
+	if end < 0 {
+		end = len(text)
+	}
+	return yamlScalar(strings.TrimSpace(text[:end])), text[end:], nil
+}
+
+// yamlScalar resolves a plain scalar to a bool, number, nil or string
+func yamlScalar(s string) interface{} {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
@@ -0,0 +1,209 @@
+package particles
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		desc string
+		src  string
+		want map[string]interface{}
+	}{
+		{"empty", "", map[string]interface{}{}},
+		{"comments only", "# comment\n\n  # indented\n", map[string]interface{}{}},
+		{"scalars", "s: text\ni: 42\nf: 1.5\nyes: true\nno: False\nnothing: ~\nempty:\ndate: 2023-05-15\n", map[string]interface{}{
+			"s": "text", "i": int64(42), "f": 1.5, "yes": true, "no": false, "nothing": nil, "empty": nil, "date": "2023-05-15",
+		}},
+		{"quoted strings", "a: \"#ff0000\"\nb: 'it''s'\nc: \"say \\\"hi\\\"\"\nd: \"a: b\"\n", map[string]interface{}{
+			"a": "#ff0000", "b": "it's", "c": `say "hi"`, "d": "a: b",
+		}},
+		{"trailing comments", "a: 1 # one\nb: \"#not a comment\" # but this is\nc: x#y\n", map[string]interface{}{
+			"a": int64(1), "b": "#not a comment", "c": "x#y",
+		}},
+		{"nested mappings", "particles:\n  preset: snow\n  move:\n    speed: 2\ntitle: x\n", map[string]interface{}{
+			"particles": map[string]interface{}{"preset": "snow", "move": map[string]interface{}{"speed": int64(2)}},
+			"title":     "x",
+		}},
+		{"block sequences", "tags:\n  - a\n  - 2\nflat:\n- b\n", map[string]interface{}{
+			"tags": []interface{}{"a", int64(2)},
+			"flat": []interface{}{"b"},
+		}},
+		{"sequence of mappings", "menu:\n  - name: a\n    weight: 1\n  - name: b\n", map[string]interface{}{
+			"menu": []interface{}{
+				map[string]interface{}{"name": "a", "weight": int64(1)},
+				map[string]interface{}{"name": "b"},
+			},
+		}},
+		{"flow collections", "color: [\"#fff\", '#000', red]\np: {a: 1, b: [x, y], c: {d: true}}\nnone: []\n", map[string]interface{}{
+			"color": []interface{}{"#fff", "#000", "red"},
+			"p":     map[string]interface{}{"a": int64(1), "b": []interface{}{"x", "y"}, "c": map[string]interface{}{"d": true}},
+			"none":  []interface{}{},
+		}},
+		{"CRLF line endings", "a: 1\r\nb:\r\n  c: 2\r\n", map[string]interface{}{
+			"a": int64(1), "b": map[string]interface{}{"c": int64(2)},
+		}},
+		{"literal block scalar", "code: |\n  line one\n    indented # not a comment\n\n  last\nnext: 1\n", map[string]interface{}{
+			"code": "line one\n  indented # not a comment\n\nlast\n", "next": int64(1),
+		}},
+		{"folded block scalar", "summary: >\n  A long\n  summary.\n\n  Second paragraph.\n\n\ntitle: x\n", map[string]interface{}{
+			"summary": "A long summary.\nSecond paragraph.\n", "title": "x",
+		}},
+		{"chomping", "strip: |-\n  a\n\nkeep: |+\n  b\n\nclip: >\n  c\n", map[string]interface{}{
+			"strip": "a", "keep": "b\n\n", "clip": "c\n",
+		}},
+		{"nested block scalar", "params:\n  description: >-\n    key: like text\n    - and a dash\n  author: me\n", map[string]interface{}{
+			"params": map[string]interface{}{"description": "key: like text - and a dash", "author": "me"},
+		}},
+		{"block scalar in a sequence", "notes:\n  - |\n    one\n  - two\n", map[string]interface{}{
+			"notes": []interface{}{"one\n", "two"},
+		}},
+		{"empty block scalar", "a: |\nb: 1\n", map[string]interface{}{"a": "", "b": int64(1)}},
+	}
+	for _, tt := range tests {
+		got, err := parseYAML([]byte(tt.src))
+		if err != nil {
+			t.Errorf("%s: %v", tt.desc, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %#v, want %#v", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		desc string
+		src  string
+		err  string
+	}{
+		{"tab indentation", "a:\n\tb: 1\n", "line 2: tabs are not allowed"},
+		{"not a mapping", "- a\n- b\n", "document is not a mapping"},
+		{"missing colon", "a: 1\njust text\n", "line 2: expected 'key: value'"},
+		{"duplicate key", "a: 1\na: 2\n", `line 2: duplicate key "a"`},
+		{"unexpected indentation", "a: 1\n  b: 2\n", "line 2: unexpected indentation"},
+		{"block scalar header", "a: |x\n  text\n", "line 1: invalid block scalar header"},
+		{"block scalar dedent", "a: |\n    text\n  less\n", "line 3: block scalar is less indented"},
+		{"mixed sequence", "a:\n  - x\n  y: 1\n", "line 3: expected sequence entry"},
+		{"unterminated flow", "a: [1, 2\n", "line 1"},
+		{"unterminated quote", "a: \"open\n", "line 1"},
+	}
+	for _, tt := range tests {
+		_, err := parseYAML([]byte(tt.src))
+		if err == nil {
+			t.Errorf("%s: no error", tt.desc)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), "yaml: ") || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: got %q, want %q", tt.desc, err, tt.err)
+		}
+	}
+}
+
+func TestParseFrontMatter(t *testing.T) {
+	tests := []struct {
+		desc    string
+		content string
+		want    *PageParticles
+		err     string
+	}{
+		{"no front matter", "# Title\n", nil, ""},
+		{"no particles block", "---\ntitle: x\n---\nbody\n", nil, ""},
+		{"empty block", "---\nparticles:\n---\n", nil, ""},
+		{"yaml preset name", "---\nparticles: snow\n---\n", &PageParticles{Preset: "snow"}, ""},
+		{"yaml mapping", "---\ntitle: x\nparticles:\n  preset: nightsky\n  color: [\"#ffffff\", \"#7ee0ff\"]\n  number: 120\n  size: 2.5\n---\n---\nnot front matter\n",
+			&PageParticles{Preset: "nightsky", Color: "#ffffff,#7ee0ff", Number: 120, Size: 2.5}, ""},
+		{"byte order mark", "\xef\xbb\xbf---\nparticles: snow\n---\n", &PageParticles{Preset: "snow"}, ""},
+		{"fence with trailing spaces", "---  \r\nparticles: snow\r\n---\r\n", &PageParticles{Preset: "snow"}, ""},
+		{"toml", "+++\ntitle = \"x\"\n[particles]\npreset = \"snow\"\nspeed = 3\n+++\n", &PageParticles{Preset: "snow", Speed: 3}, ""},
+		{"json", "{\"particles\": {\"preset\": \"snow\", \"number\": 50}}\nbody\n", &PageParticles{Preset: "snow", Number: 50}, ""},
+		{"unclosed yaml", "---\nparticles: snow\n", nil, `not closed by "---"`},
+		{"unclosed toml", "+++\nparticles = \"snow\"\n", nil, `not closed by "+++"`},
+		{"malformed fence", "----\nparticles: snow\n----\n", nil, "malformed front matter delimiter"},
+		{"yaml syntax error", "---\nparticles:\n\tpreset: snow\n---\n", nil, "yaml: line 2"},
+		{"toml syntax error", "+++\nparticles = \n+++\n", nil, "toml: line 1"},
+		{"json syntax error", "{\"particles\": \n", nil, "json front matter"},
+		{"unsupported yaml without particles", "---\ntitle: x\nref: *anchor\nlist: [a\n---\n", nil, ""},
+		{"unsupported toml without particles", "+++\ntitle = \"x\"\nwhen = 2023-13-45\n+++\n", nil, ""},
+		{"hugo yaml", "---\ntitle: Post\ndate: 2023-05-15T10:00:00Z\nsummary: >\n  Folded\n  text.\nparticles: snow\n---\n", &PageParticles{Preset: "snow"}, ""},
+		{"hugo toml", "+++\ntitle = \"Post\"\ndate = 2023-05-15T10:00:00Z\ndescription = \"\"\"\nMore text\n\"\"\"\n[particles]\npreset = \"snow\"\n+++\n", &PageParticles{Preset: "snow"}, ""},
+		{"wrong block type", "---\nparticles: [snow]\n---\n", nil, "expected a preset name or a mapping"},
+		{"unknown key", "---\nparticles:\n  speeed: 2\n---\n", nil, "particles.speeed: unknown key"},
+		{"fractional number", "---\nparticles:\n  number: 1.5\n---\n", nil, "expected a whole number"},
+		{"string number", "---\nparticles:\n  size: big\n---\n", nil, "expected a number"},
+	}
+	for _, tt := range tests {
+		got, err := ParseFrontMatter([]byte(tt.content))
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: got error %v, want %q", tt.desc, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.desc, err)
+		} else if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestParseFrontMatterDemo(t *testing.T) {
+	paths, err := filepath.Glob("../hugo-demo/content/*.md")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no demo content: %v", err)
+	}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := frontMatter(content); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+
+	content, err := os.ReadFile("../hugo-demo/content/frontmatter.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := ParseFrontMatter(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &PageParticles{Preset: PresetNightSky, Color: "#ffffff,#7ee0ff,#ffdd00", Number: 120}
+	if !reflect.DeepEqual(page, want) {
+		t.Errorf("got %+v, want %+v", page, want)
+	}
+}
+
+func TestScanContentDemo(t *testing.T) {
+	site, err := LoadSiteParams("../hugo-demo/config.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := ScanContent("../hugo-demo/content", site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || filepath.Base(results[0].Path) != "frontmatter.md" {
+		t.Fatalf("got %+v, want frontmatter.md alone", results)
+	}
+	if results[0].Err != nil || results[0].Config == nil {
+		t.Errorf("frontmatter.md: %v", results[0].Err)
+	}
+
+	var errs ValidationErrors
+	bad := t.TempDir()
+	os.WriteFile(filepath.Join(bad, "bad.md"), []byte("---\nparticles:\n  preset: nope\n---\n"), 0o644)
+	results, err = ScanContent(bad, site)
+	if err != nil || len(results) != 1 || !errors.As(results[0].Err, &errs) {
+		t.Errorf("invalid page: got %+v, %v", results, err)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/yourusername/particles-go/particles"
+)
+
+// serveOptions holds the settings of the serve command
+type serveOptions struct {
+	addr            string
+	endpoint        string
+	staticDir       string
+	presetDir       string
+	store           string
+	jsPath          string
+	format          string
+	tlsCert         string
+	tlsKey          string
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	idleTimeout     time.Duration
+	shutdownTimeout time.Duration
+	rateLimit       float64
+	rateBurst       int
+	randomLimit     int
+	randomWindow    time.Duration
+	maxBody         int64
+	allow           string
+	trustProxy      bool
+	adminKeys       string
+	auditLog        string
+	corsOrigins     string
+	corsMaxAge      time.Duration
+	cdnFallback     bool
+}
+
+func runServe(args []string, stdout, stderr io.Writer) int {
+	var opts serveOptions
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&opts.addr, "addr", envOr(envAddr, ":8080"), "address to listen on")
+	flags.StringVar(&opts.endpoint, "endpoint", envOr(envEndpoint, "/api/particles-config"), "configuration endpoint path")
+	flags.StringVar(&opts.staticDir, "static", envOr(envStaticDir, ""), "directory whose files override the embedded assets")
+	flags.StringVar(&opts.presetDir, "presets", envOr(envPresetDir, ""), "directory of <name>.json presets to load")
+	flags.StringVar(&opts.store, "store", envOr(envStore, "memory"), "store backend: memory, memory:<capacity> or file:<dir>")
+	flags.StringVar(&opts.jsPath, "js-path", envOr(envJsPath, ""), "URL of particles.min.js (default the embedded copy)")
+	flags.StringVar(&opts.format, "format", envOr(envFormat, particles.FormatParticlesJS), "format served by default: particlesjs or tsparticles")
+	flags.StringVar(&opts.tlsCert, "tls-cert", envOr(envTLSCert, ""), "TLS certificate file; serves HTTPS together with -tls-key")
+	flags.StringVar(&opts.tlsKey, "tls-key", envOr(envTLSKey, ""), "TLS private key file")
+	flags.DurationVar(&opts.readTimeout, "read-timeout", 10*time.Second, "maximum duration for reading a request")
+	flags.DurationVar(&opts.writeTimeout, "write-timeout", 10*time.Second, "maximum duration for writing a response")
+	flags.DurationVar(&opts.idleTimeout, "idle-timeout", 60*time.Second, "how long keep-alive connections stay open while idle")
+	flags.DurationVar(&opts.shutdownTimeout, "shutdown-timeout", 15*time.Second, "how long in-flight requests may take to finish on shutdown")
+	flags.Float64Var(&opts.rateLimit, "rate-limit", 10, "requests per second allowed per client IP; 0 disables rate limiting")
+	flags.IntVar(&opts.rateBurst, "rate-burst", 20, "requests a client IP may make in a burst")
+	flags.IntVar(&opts.randomLimit, "random-limit", 30, "random configs a client IP may generate per -random-window; 0 disables the cap")
+	flags.DurationVar(&opts.randomWindow, "random-window", time.Minute, "window of -random-limit")
+	flags.Int64Var(&opts.maxBody, "max-body", 1<<20, "largest request body accepted, in bytes")
+	flags.StringVar(&opts.allow, "allow", envOr(envAllow, ""), "comma separated IPs and CIDR networks exempt from rate limits")
+	flags.BoolVar(&opts.trustProxy, "trust-proxy", false, "take client IPs from X-Forwarded-For; only set behind a reverse proxy")
+	flags.StringVar(&opts.adminKeys, "admin-keys", envOr(envAdminKeys, ""), "key file enabling writes through the admin API")
+	flags.StringVar(&opts.auditLog, "audit-log", envOr(envAuditLog, ""), "file admin API changes are appended to")
+	flags.StringVar(&opts.corsOrigins, "cors-origins", envOr(envCORSOrigins, ""), "comma separated origins allowed to load configs, \"*\" for any or \"dev\" for the local dev servers")
+	flags.DurationVar(&opts.corsMaxAge, "cors-max-age", 10*time.Minute, "how long browsers may cache CORS preflight responses")
+	flags.BoolVar(&opts.cdnFallback, "cdn-fallback", true, "point pages at the cdnjs copy of particles.js when the binary doesn't embed it")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if opts.format != particles.FormatParticlesJS && opts.format != particles.FormatTSParticles {
+		fmt.Fprintf(stderr, "particles-go: unknown format %q\n", opts.format)
+		return exitUsage
+	}
+	if opts.rateLimit > 0 && opts.rateBurst < 1 {
+		fmt.Fprintln(stderr, "particles-go: -rate-burst must be at least 1")
+		return exitUsage
+	}
+	if opts.randomLimit > 0 && opts.randomWindow <= 0 {
+		fmt.Fprintln(stderr, "particles-go: -random-window must be positive")
+		return exitUsage
+	}
+	if (opts.tlsCert == "") != (opts.tlsKey == "") {
+		fmt.Fprintln(stderr, "particles-go: -tls-cert and -tls-key must be set together")
+		return exitUsage
+	}
+
+	logger := slog.New(slog.NewJSONHandler(stderr, nil))
+	slog.SetDefault(logger)
+
+	srv, err := newServer(opts, logger)
+	if err != nil {
+		fmt.Fprintf(stderr, "particles-go: %v\n", err)
+		return exitFailure
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := listenAndServe(ctx, srv, opts, logger); err != nil {
+		fmt.Fprintf(stderr, "particles-go: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// newServer sets up the handlers and the HTTP server for opts
+func newServer(opts serveOptions, logger *slog.Logger) (*http.Server, error) {
+	configStore, err := particles.OpenStore(opts.store)
+	if err != nil {
+		return nil, err
+	}
+	allow, err := particles.ParseIPAllowList(opts.allow)
+	if err != nil {
+		return nil, err
+	}
+	ready := &readiness{store: configStore}
+
+	// Load presets in the background; /readyz fails until they are loaded
+	// and keeps failing if loading them failed
+	go func() {
+		if opts.presetDir == "" {
+			ready.presetsLoaded(nil)
+			return
+		}
+		names, err := particles.LoadPresetDir(opts.presetDir)
+		if err != nil {
+			logger.Error("error loading presets", "dir", opts.presetDir, "error", err)
+		} else {
+			logger.Info("loaded presets", "dir", opts.presetDir, "count", len(names))
+		}
+		ready.presetsLoaded(err)
+	}()
+
+	assets, err := newAssetServer(opts.staticDir)
+	if err != nil {
+		return nil, fmt.Errorf("error loading embedded assets: %v", err)
+	}
+	if opts.jsPath == "" {
+		opts.jsPath = runtimePath
+		if !assets.has(runtimePath) {
+			if !opts.cdnFallback {
+				return nil, fmt.Errorf("particles.js is not embedded; run go generate before building, or pass -js-path")
+			}
+			logger.Warn("particles.js is not embedded, pages load it from the CDN")
+			opts.jsPath = runtimeCDN
+			assets.manifest.AddExternal(runtimeCDN, runtimeIntegrity)
+		}
+	}
+
+	// Create a new particles handler for Hugo
+	particlesHandler := particles.NewHugoHandler(opts.endpoint, opts.jsPath)
+	particlesHandler.Store = configStore
+	particlesHandler.Format = opts.format
+	particlesHandler.Assets = assets.manifest
+	metrics := particles.NewMetrics()
+	metrics.Store = configStore
+	particlesHandler.Metrics = metrics
+	if origins := particles.ParseOrigins(opts.corsOrigins); len(origins) > 0 {
+		particlesHandler.CORS = particles.NewCORSPolicy(origins...)
+		particlesHandler.CORS.MaxAge = opts.corsMaxAge
+	}
+	if opts.randomLimit > 0 {
+		particlesHandler.RandomLimiter = particles.ExemptLimiter{
+			Limiter: particles.NewWindowLimiter(opts.randomLimit, opts.randomWindow),
+			Exempt:  allow,
+		}
+	}
+	if err := configStore.Put(particlesHandler.DefaultConfigID, particles.DefaultConfig()); err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+
+	// Register the handler to serve particle configs
+	mux.Handle(opts.endpoint, particlesHandler)
+
+	// Serve the client assets and the control-panel demo
+	mux.Handle("/js/", jsonErrors(assets))
+	mux.Handle(demoPath, jsonErrors(assets))
+
+	// Config and preset API; writes need a key from -admin-keys
+	admin := particles.NewAdminHandler(configStore)
+	admin.PresetDir = opts.presetDir
+	if opts.adminKeys != "" {
+		if admin.Keys, err = particles.LoadKeyRing(opts.adminKeys); err != nil {
+			return nil, err
+		}
+	}
+	if opts.auditLog != "" {
+		if admin.Audit, err = particles.OpenAuditLog(opts.auditLog); err != nil {
+			return nil, err
+		}
+	}
+	mux.Handle(admin.Prefix+"/configs/", admin)
+	mux.Handle(admin.Prefix+"/presets", admin)
+	mux.Handle(admin.Prefix+"/presets/", admin)
+
+	// Probes for load balancers and reverse proxies
+	registerHealth(mux, ready)
+
+	// Prometheus metrics
+	mux.Handle("/metrics", metrics)
+
+	guard := &requestGuard{
+		trustProxy: opts.trustProxy,
+		allow:      allow,
+		maxBody:    opts.maxBody,
+	}
+	if opts.rateLimit > 0 {
+		guard.limiter = particles.NewTokenBucketLimiter(opts.rateLimit, opts.rateBurst)
+	}
+
+	return &http.Server{
+		Addr:              opts.addr,
+		Handler:           serveMux(mux, guard, metrics, logger),
+		ReadTimeout:       opts.readTimeout,
+		ReadHeaderTimeout: opts.readTimeout,
+		WriteTimeout:      opts.writeTimeout,
+		IdleTimeout:       opts.idleTimeout,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}, nil
+}
+
+// listenAndServe runs srv until ctx is cancelled, then stops accepting
+// connections and waits for in-flight requests to finish
+func listenAndServe(ctx context.Context, srv *http.Server, opts serveOptions, logger *slog.Logger) error {
+	errc := make(chan error, 1)
+	go func() {
+		logger.Info("starting server", "addr", srv.Addr, "tls", opts.tlsCert != "", "version", currentBuild().Version)
+		if opts.tlsCert != "" {
+			errc <- srv.ListenAndServeTLS(opts.tlsCert, opts.tlsKey)
+		} else {
+			errc <- srv.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutting down", "timeout", opts.shutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), opts.shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("error shutting down: %v", err)
+	}
+
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	logger.Info("server stopped")
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testServeOptions returns the serve defaults, with particles.js loaded
+// from elsewhere so that tests pass without go generate
+func testServeOptions() serveOptions {
+	return serveOptions{
+		addr:            "127.0.0.1:0",
+		endpoint:        "/api/particles-config",
+		store:           "memory",
+		jsPath:          "/js/particles.test.js",
+		format:          "particlesjs",
+		readTimeout:     time.Second,
+		writeTimeout:    2 * time.Second,
+		idleTimeout:     3 * time.Second,
+		shutdownTimeout: time.Second,
+		maxBody:         1 << 20,
+		cdnFallback:     true,
+	}
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(io.Discard, nil))
+}
+
+func TestRunServeUsage(t *testing.T) {
+	tests := []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{"-format", "xml"}, "unknown format"},
+		{[]string{"-rate-burst", "0"}, "-rate-burst must be at least 1"},
+		{[]string{"-random-window", "0s"}, "-random-window must be positive"},
+		{[]string{"-tls-cert", "cert.pem"}, "must be set together"},
+		{[]string{"-tls-key", "key.pem"}, "must be set together"},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := runServe(tt.args, &stdout, &stderr); code != exitUsage {
+			t.Errorf("%v: exit %d, want %d", tt.args, code, exitUsage)
+		}
+		if !strings.Contains(stderr.String(), tt.msg) {
+			t.Errorf("%v: got %q, want %q", tt.args, stderr.String(), tt.msg)
+		}
+	}
+}
+
+func TestNewServer(t *testing.T) {
+	opts := testServeOptions()
+	srv, err := newServer(opts, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srv.ReadTimeout != opts.readTimeout || srv.ReadHeaderTimeout != opts.readTimeout ||
+		srv.WriteTimeout != opts.writeTimeout || srv.IdleTimeout != opts.idleTimeout {
+		t.Errorf("server timeouts not taken from the options: %+v", srv)
+	}
+
+	opts.store = "redis://localhost"
+	if _, err := newServer(opts, discardLogger()); err == nil {
+		t.Error("unknown store accepted")
+	}
+}
+
+func TestNewServerRuntime(t *testing.T) {
+	assets, err := newAssetServer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assets.has(runtimePath) {
+		t.Skip("particles.js is embedded")
+	}
+
+	// By default pages load the pinned CDN copy instead
+	opts := testServeOptions()
+	opts.jsPath = ""
+	if _, err := newServer(opts, discardLogger()); err != nil {
+		t.Errorf("defaults: %v", err)
+	}
+	opts.cdnFallback = false
+	if _, err := newServer(opts, discardLogger()); err == nil || !strings.Contains(err.Error(), "go generate") {
+		t.Errorf("with -cdn-fallback=false: got %v, want an error asking for go generate", err)
+	}
+}
+
+func TestListenAndServeShutdown(t *testing.T) {
+	opts := testServeOptions()
+	srv, err := newServer(opts, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- listenAndServe(ctx, srv, opts, discardLogger()) }()
+	cancel()
+
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Errorf("got %v, want a clean shutdown", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server didn't stop")
+	}
+}
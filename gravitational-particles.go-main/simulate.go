@@ -0,0 +1,157 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/yourusername/particles-go/particles"
+	"github.com/yourusername/particles-go/particles/physics"
+)
+
+func runSimulate(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	preset := flags.String("preset", "", "preset whose gravity simulation to run")
+	configFile := flags.String("config", "", "particles.js configuration file whose gravity simulation to run")
+	presetDir := flags.String("presets", envOr(envPresetDir, ""), "directory of <name>.json presets to include")
+	steps := flags.Int("steps", 600, "number of steps (animation frames) to run")
+	width := flags.Float64("width", 1280, "canvas width")
+	height := flags.Float64("height", 800, "canvas height")
+	seed := flags.Int64("seed", 1, "seed placing the planets")
+	format := flags.String("format", physics.TrajectoryCSV, "trajectory format: "+strings.Join(physics.TrajectoryFormats(), ", "))
+	every := flags.Int("every", 1, "record every n-th step")
+	out := flags.String("o", "-", "trajectory file, - for stdout, empty for none")
+	summaryFile := flags.String("summary", "", "write the summary as JSON to this file, - for stdout (default: text on stderr)")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: particles-go simulate [-preset name | -config file] [flags]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Configurations without a gravity section run the gravity.js defaults.")
+		fmt.Fprintln(stderr, "Simulations with a warm-up start from its settled state.")
+		flags.PrintDefaults()
+	}
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() != 0 || (*preset != "" && *configFile != "") {
+		flags.Usage()
+		return exitUsage
+	}
+	if *steps < 0 || *every < 1 || *width <= 0 || *height <= 0 {
+		fmt.Fprintln(stderr, "particles-go: -steps must not be negative, -every and the canvas size must be positive")
+		return exitUsage
+	}
+	if !validFormat(*format) {
+		fmt.Fprintf(stderr, "particles-go: unknown trajectory format %q, expected one of %s\n", *format, strings.Join(physics.TrajectoryFormats(), ", "))
+		return exitUsage
+	}
+	if *out == "-" && *summaryFile == "-" {
+		fmt.Fprintln(stderr, "particles-go: -o and -summary can't both write to stdout; set -o to a file, or to \"\" for no trajectory")
+		return exitUsage
+	}
+	if !loadPresets(*presetDir, stderr) {
+		return exitFailure
+	}
+
+	config := &particles.Config{}
+	switch {
+	case *preset != "":
+		if !particles.IsPreset(*preset) {
+			fmt.Fprintf(stderr, "particles-go: unknown preset %q\n", *preset)
+			return exitFailure
+		}
+		config = particles.GetPreset(*preset)
+	case *configFile != "":
+		data, err := os.ReadFile(*configFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "particles-go: %v\n", err)
+			return exitFailure
+		}
+		if config, err = particles.DecodeConfig(data); err != nil {
+			fmt.Fprintf(stderr, "particles-go: %s: %v\n", *configFile, err)
+			return exitFailure
+		}
+	}
+	gravity := config.GravitySettings()
+
+	// Start where browsers do
+	if gravity.Warmup.Steps > 0 && gravity.State == nil {
+		state, err := physics.WarmUp(gravity)
+		if err != nil {
+			fmt.Fprintf(stderr, "particles-go: %v\n", err)
+			return exitFailure
+		}
+		gravity.State = state
+	}
+
+	var trajectory physics.TrajectoryWriter
+	if *out != "" {
+		w := stdout
+		if *out != "-" {
+			f, err := os.Create(*out)
+			if err != nil {
+				fmt.Fprintf(stderr, "particles-go: %v\n", err)
+				return exitFailure
+			}
+			defer f.Close()
+			w = f
+		}
+		var err error
+		if trajectory, err = physics.NewTrajectoryWriter(*format, w); err != nil {
+			fmt.Fprintf(stderr, "particles-go: %v\n", err)
+			return exitUsage
+		}
+	}
+
+	world := physics.NewWorld(gravity, *width, *height, *seed)
+	summary, err := physics.Simulate(world, *steps, *every, trajectory)
+	if err != nil {
+		fmt.Fprintf(stderr, "particles-go: %v\n", err)
+		return exitFailure
+	}
+
+	switch *summaryFile {
+	case "":
+		printSummary(stderr, summary)
+	case "-":
+		if err := writeJSON(stdout, summary); err != nil {
+			fmt.Fprintf(stderr, "particles-go: %v\n", err)
+			return exitFailure
+		}
+	default:
+		f, err := os.Create(*summaryFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "particles-go: %v\n", err)
+			return exitFailure
+		}
+		defer f.Close()
+		if err := writeJSON(f, summary); err != nil {
+			fmt.Fprintf(stderr, "particles-go: %v\n", err)
+			return exitFailure
+		}
+	}
+	return exitOK
+}
+
+// validFormat reports whether format is a trajectory format
+func validFormat(format string) bool {
+	for _, f := range physics.TrajectoryFormats() {
+		if format == f {
+			return true
+		}
+	}
+	return false
+}
+
+// printSummary writes the statistics of a run for people to read
+func printSummary(w io.Writer, s physics.Summary) {
+	fmt.Fprintf(w, "%d steps, %d planets\n", s.Steps, s.Bodies)
+	fmt.Fprintf(w, "escaped:    %d outside the canvas, %d unbound, %d exits\n", s.Escaped, s.Unbound, s.Exits)
+	fmt.Fprintf(w, "emitted:    %d, %d expired\n", s.Emitted, s.Expired)
+	fmt.Fprintf(w, "bounces:    %d off the cursor, %d collisions, %d merges\n", s.Bounces, s.Collisions, s.Merges)
+	fmt.Fprintf(w, "max speed:  %.3f\n", s.MaxSpeed)
+	fmt.Fprintf(w, "energy:     %.6g -> %.6g, drift %.3e (max %.3e)\n",
+		s.Initial.Energy, s.Final.Energy, s.EnergyDrift, s.MaxEnergyDrift)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunSimulateUsage(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "run.out")
+
+	tests := []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{"-format", "xml", "-o", out}, "unknown trajectory format"},
+		{[]string{"-summary", "-"}, "both write to stdout"},
+		{[]string{"-every", "0"}, "must be positive"},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := runSimulate(tt.args, &stdout, &stderr); code != exitUsage {
+			t.Errorf("%v: exit %d, want %d", tt.args, code, exitUsage)
+		}
+		if !strings.Contains(stderr.String(), tt.msg) {
+			t.Errorf("%v: got %q, want %q", tt.args, stderr.String(), tt.msg)
+		}
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Error("trajectory file created for a rejected run")
+	}
+}
+
+func TestRunSimulate(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"-steps", "4", "-every", "2", "-format", "jsonl", "-summary", filepath.Join(t.TempDir(), "summary.json")}
+	if code := runSimulate(args, &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	// The initial state and steps 2 and 4
+	if lines := strings.Count(stdout.String(), "\n"); lines != 3 {
+		t.Errorf("wrote %d frames, want 3", lines)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/particles-go/particles"
+)
+
+// diagnostic is a single problem found by the validate command
+type diagnostic struct {
+	File    string `json:"file"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+func (d diagnostic) String() string {
+	if d.Path == "" {
+		return fmt.Sprintf("%s: %s", d.File, d.Message)
+	}
+	return fmt.Sprintf("%s:%s: %s", d.File, d.Path, d.Message)
+}
+
+func runValidate(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "text", "output format: text or json")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: particles-go validate [-format text|json] <file or directory>...")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Directories are searched recursively for .json files.")
+		flags.PrintDefaults()
+	}
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "particles-go: unknown format %q\n", *format)
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	files, err := configFiles(flags.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "particles-go: %v\n", err)
+		return exitFailure
+	}
+
+	diagnostics := []diagnostic{}
+	for _, file := range files {
+		diagnostics = append(diagnostics, validateFile(file)...)
+	}
+
+	if *format == "json" {
+		if err := writeJSON(stdout, struct {
+			Files       int          `json:"files"`
+			Diagnostics []diagnostic `json:"diagnostics"`
+		}{len(files), diagnostics}); err != nil {
+			fmt.Fprintf(stderr, "particles-go: %v\n", err)
+			return exitFailure
+		}
+	} else {
+		for _, d := range diagnostics {
+			fmt.Fprintln(stdout, d)
+		}
+	}
+
+	if len(diagnostics) > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// configFiles expands the arguments into files, searching directories
+// recursively for JSON files
+func configFiles(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+
+		err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".json") {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// validateFile decodes and validates one configuration file
+func validateFile(file string) []diagnostic {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return []diagnostic{{File: file, Message: err.Error()}}
+	}
+
+	_, err = particles.DecodeConfig(data)
+	if err == nil {
+		return nil
+	}
+
+	var errs particles.ValidationErrors
+	if !errors.As(err, &errs) {
+		return []diagnostic{{File: file, Message: err.Error()}}
+	}
+
+	diagnostics := make([]diagnostic, len(errs))
+	for i, e := range errs {
+		diagnostics[i] = diagnostic{File: file, Path: e.Path, Message: e.Message}
+	}
+	return diagnostics
+}
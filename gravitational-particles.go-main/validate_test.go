@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunValidate(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	unknown := filepath.Join(dir, "nested", "unknown.json")
+	invalid := filepath.Join(dir, "nested", "invalid.json")
+	writeFile(t, valid, `{"particles": {"number": {"value": 40}}}`)
+	writeFile(t, unknown, `{"particles": {"numbr": {}}}`)
+	writeFile(t, invalid, `{"particles": {"opacity": {"value": 2}, "move": {"direction": "up"}}}`)
+	writeFile(t, filepath.Join(dir, "notes.txt"), "not a configuration")
+
+	var stdout, stderr bytes.Buffer
+	if code := runValidate([]string{valid}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("valid file: exit %d: %s", code, stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("valid file: got output %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := runValidate([]string{dir}, &stdout, &stderr); code != exitFailure {
+		t.Fatalf("directory: exit %d, want %d", code, exitFailure)
+	}
+	want := []string{
+		invalid + ":particles.opacity.value: must be between 0 and 1, got 2",
+		invalid + ":particles.move.direction: unknown value",
+		unknown + ":particles.numbr: unknown field",
+	}
+	got := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("directory: got %q, want %q", got, want)
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("directory: got %q, want %q", got[i], want[i])
+		}
+	}
+
+	stdout.Reset()
+	if code := runValidate([]string{"-format", "json", dir}, &stdout, &stderr); code != exitFailure {
+		t.Fatalf("json: exit %d, want %d", code, exitFailure)
+	}
+	var report struct {
+		Files       int          `json:"files"`
+		Diagnostics []diagnostic `json:"diagnostics"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Files != 3 || len(report.Diagnostics) != 3 || report.Diagnostics[2].Path != "particles.numbr" {
+		t.Errorf("json: got %+v", report)
+	}
+}
+
+func TestRunValidateUsage(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{nil, exitUsage},
+		{[]string{"-format", "xml", "."}, exitUsage},
+		{[]string{filepath.Join(t.TempDir(), "missing.json")}, exitFailure},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := runValidate(tt.args, &stdout, &stderr); code != tt.code {
+			t.Errorf("%v: exit %d, want %d", tt.args, code, tt.code)
+		}
+	}
+}